@@ -30,7 +30,8 @@ func main() {
 
 	// Open DB with SQL logging enabled
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger:         logger.Default.LogMode(logger.Info),
+		TranslateError: true,
 	})
 	if err != nil {
 		log.Fatalf("failed to connect database: %v", err)
@@ -77,6 +78,16 @@ func main() {
 	if err := db.AutoMigrate(
 		&model.PlateLog{},
 		&model.User{},
+		&model.MemberReconciliation{},
+		&model.MemberCacheEntry{},
+		&model.RetentionPolicy{},
+		&model.ParkingSession{},
+		&model.Location{},
+		&model.Camera{},
+		&model.AuthSession{},
+		&model.APIKey{},
+		&model.LoginThrottle{},
+		&model.AuthEvent{},
 	); err != nil {
 		log.Fatalf("auto migration failed: %v", err)
 	}
@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -16,6 +18,95 @@ type Env struct {
 	DBPassword           string
 	DBRootPassword       string
 	PlateRecognizerToken string
+	RecognitionEngine    string
+	OpenALPRURL          string
+	OpenALPRSecretKey    string
+	OpenALPRCountry      string
+	FakeEnginePlate      string
+
+	// Plate-reader pool, endpoints are "url" or "url|weight" separated by commas
+	PlateReaderEndpoints        string
+	PlateReaderStrategy         string
+	PlateReaderProbeInterval    time.Duration
+	PlateReaderProbeTimeout     time.Duration
+	PlateReaderFailureThreshold int
+	PlateReaderCooldown         time.Duration
+
+	// Recognition deadline budget, split across the pipeline stages.
+	// ImageUploadTimeout bounds each background upload attempt.
+	RecognizeTimeout    time.Duration
+	EngineTimeout       time.Duration
+	MemberLookupTimeout time.Duration
+	ImageUploadTimeout  time.Duration
+	DBWriteTimeout      time.Duration
+
+	// Member service
+	MemberClient            string
+	MemberServiceURL        string
+	MemberServiceAuthHeader string
+	MemberServiceAuthToken  string
+	MemberServiceTimeout    time.Duration
+	MemberServiceRetries    int
+
+	// Member outage handling: strict fails the recognition, degraded
+	// serves MemberDegradedCategory and queues the lookup for later
+	MemberFailurePolicy     string
+	MemberDegradedCategory  string
+	MemberReconcileInterval time.Duration
+
+	// Member cache: off, memory or db (shared between instances)
+	MemberCache              string
+	MemberCacheTTL           time.Duration
+	MemberCacheNegativeTTL   time.Duration
+	MemberCacheMaxEntries    int
+	MemberCacheWebhookSecret string
+
+	// Image storage, uploads go through a local spool
+	MinioEndpoint         string
+	MinioBucket           string
+	ImageKeyTemplate      string
+	ImageSpoolDir         string
+	ImageUploadWorkers    int
+	ImageUploadMaxBackoff time.Duration
+	ImageURLExpiry        time.Duration
+
+	// Retention, 0 days keeps data forever
+	RetentionEnabled      bool
+	RetentionInterval     time.Duration
+	RetentionImageDays    int
+	RetentionMetadataDays int
+
+	// Parking sessions, repeated entry reads within the window are merged
+	ParkingSessionDuplicateWindow time.Duration
+
+	// Reads below this score go to review unless the camera overrides it
+	MinConfidence float64
+
+	// Plate format used when a camera has no region, e.g. "id"
+	PlateCountry string
+
+	// Fuzzy plate matching for member lookups and session pairing,
+	// 0 lookups disables it for members
+	PlateMatchMinSimilarity float64
+	PlateMatchMemberLookups int
+
+	// API authentication, form credentials are opt-in for old gates
+	JWTSecret       string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	AuthLegacyForm  bool
+
+	// Lets /api/register create the first admin, unset it afterwards
+	BootstrapToken string
+
+	// Password login throttling: every failure delays the next attempt,
+	// too many within the window lock the username or IP out
+	LoginMaxFailures   int
+	LoginIPMaxFailures int
+	LoginLockout       time.Duration
+	LoginFailureWindow time.Duration
+	LoginDelayBase     time.Duration
+	LoginDelayMax      time.Duration
 }
 
 func LoadEnv() *Env {
@@ -33,5 +124,126 @@ func LoadEnv() *Env {
 		DBPassword:           os.Getenv("BLUEPRINT_DB_PASSWORD"),
 		DBRootPassword:       os.Getenv("BLUEPRINT_DB_ROOT_PASSWORD"),
 		PlateRecognizerToken: os.Getenv("PLATE_RECOGNIZER_TOKEN"),
+		RecognitionEngine:    os.Getenv("RECOGNITION_ENGINE"),
+		OpenALPRURL:          os.Getenv("OPENALPR_URL"),
+		OpenALPRSecretKey:    os.Getenv("OPENALPR_SECRET_KEY"),
+		OpenALPRCountry:      os.Getenv("OPENALPR_COUNTRY"),
+		FakeEnginePlate:      os.Getenv("FAKE_ENGINE_PLATE"),
+
+		PlateReaderEndpoints: getEnv(
+			"PLATE_READER_ENDPOINTS",
+			"http://plate-recognizer-1:8080,http://plate-recognizer-2:8081",
+		),
+		PlateReaderStrategy:         getEnv("PLATE_READER_STRATEGY", "round_robin"),
+		PlateReaderProbeInterval:    getDuration("PLATE_READER_PROBE_INTERVAL", 5*time.Second),
+		PlateReaderProbeTimeout:     getDuration("PLATE_READER_PROBE_TIMEOUT", 2*time.Second),
+		PlateReaderFailureThreshold: getInt("PLATE_READER_FAILURE_THRESHOLD", 3),
+		PlateReaderCooldown:         getDuration("PLATE_READER_COOLDOWN", 30*time.Second),
+
+		RecognizeTimeout:    getDuration("RECOGNIZE_TIMEOUT", 20*time.Second),
+		EngineTimeout:       getDuration("ENGINE_TIMEOUT", 10*time.Second),
+		MemberLookupTimeout: getDuration("MEMBER_LOOKUP_TIMEOUT", 3*time.Second),
+		ImageUploadTimeout:  getDuration("IMAGE_UPLOAD_TIMEOUT", 5*time.Second),
+		DBWriteTimeout:      getDuration("DB_WRITE_TIMEOUT", 2*time.Second),
+
+		MemberClient:            os.Getenv("MEMBER_CLIENT"),
+		MemberServiceURL:        getEnv("MEMBER_SERVICE_URL", "http://backend-app-local:5000"),
+		MemberServiceAuthHeader: getEnv("MEMBER_SERVICE_AUTH_HEADER", "Authorization"),
+		MemberServiceAuthToken:  os.Getenv("MEMBER_SERVICE_AUTH_TOKEN"),
+		MemberServiceTimeout:    getDuration("MEMBER_SERVICE_TIMEOUT", 2*time.Second),
+		MemberServiceRetries:    getInt("MEMBER_SERVICE_RETRIES", 1),
+
+		MemberFailurePolicy:     getEnv("MEMBER_FAILURE_POLICY", "strict"),
+		MemberDegradedCategory:  getEnv("MEMBER_DEGRADED_CATEGORY", "UNKNOWN"),
+		MemberReconcileInterval: getDuration("MEMBER_RECONCILE_INTERVAL", time.Minute),
+
+		MemberCache:              getEnv("MEMBER_CACHE", "memory"),
+		MemberCacheTTL:           getDuration("MEMBER_CACHE_TTL", 10*time.Minute),
+		MemberCacheNegativeTTL:   getDuration("MEMBER_CACHE_NEGATIVE_TTL", time.Minute),
+		MemberCacheMaxEntries:    getInt("MEMBER_CACHE_MAX_ENTRIES", 100000),
+		MemberCacheWebhookSecret: os.Getenv("MEMBER_CACHE_WEBHOOK_SECRET"),
+
+		MinioEndpoint:         os.Getenv("MINIO_ENDPOINT"),
+		MinioBucket:           os.Getenv("MINIO_BUCKET_IMAGE_LPR"),
+		ImageKeyTemplate:      os.Getenv("MINIO_OBJECT_KEY_TEMPLATE"),
+		ImageSpoolDir:         getEnv("IMAGE_SPOOL_DIR", "./spool"),
+		ImageUploadWorkers:    getInt("IMAGE_UPLOAD_WORKERS", 4),
+		ImageUploadMaxBackoff: getDuration("IMAGE_UPLOAD_MAX_BACKOFF", 10*time.Minute),
+		ImageURLExpiry:        getDuration("IMAGE_URL_EXPIRY", 15*time.Minute),
+
+		RetentionEnabled:      os.Getenv("RETENTION_ENABLED") == "true",
+		RetentionInterval:     getDuration("RETENTION_INTERVAL", 24*time.Hour),
+		RetentionImageDays:    getInt("RETENTION_IMAGE_DAYS", 90),
+		RetentionMetadataDays: getInt("RETENTION_METADATA_DAYS", 730),
+
+		ParkingSessionDuplicateWindow: getDuration("PARKING_SESSION_DUPLICATE_WINDOW", 2*time.Minute),
+
+		MinConfidence: getFloat("MIN_CONFIDENCE", 0),
+		PlateCountry:  getEnv("PLATE_COUNTRY", "id"),
+
+		PlateMatchMinSimilarity: getFloat("PLATE_MATCH_MIN_SIMILARITY", 0.8),
+		PlateMatchMemberLookups: getInt("PLATE_MATCH_MEMBER_LOOKUPS", 3),
+
+		JWTSecret:       os.Getenv("JWT_SECRET"),
+		AccessTokenTTL:  getDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: getDuration("REFRESH_TOKEN_TTL", 7*24*time.Hour),
+		AuthLegacyForm:  os.Getenv("AUTH_LEGACY_FORM") == "true",
+		BootstrapToken:  os.Getenv("BOOTSTRAP_TOKEN"),
+
+		LoginMaxFailures:   getInt("LOGIN_MAX_FAILURES", 5),
+		LoginIPMaxFailures: getInt("LOGIN_IP_MAX_FAILURES", 20),
+		LoginLockout:       getDuration("LOGIN_LOCKOUT", 15*time.Minute),
+		LoginFailureWindow: getDuration("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+		LoginDelayBase:     getDuration("LOGIN_DELAY_BASE", time.Second),
+		LoginDelayMax:      getDuration("LOGIN_DELAY_MAX", 30*time.Second),
+	}
+}
+
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func getInt(key string, fallback int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("invalid %s=%q, using %d", key, v, fallback)
+		return fallback
+	}
+	return n
+}
+
+func getDuration(key string, fallback time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("invalid %s=%q, using %s", key, v, fallback)
+		return fallback
+	}
+	return d
+}
+
+func getFloat(key string, fallback float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		log.Printf("invalid %s=%q, using %v", key, v, fallback)
+		return fallback
 	}
+	return f
 }
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"errors"
+	"time"
+
+	"plate-recognizer-api/model"
+	"plate-recognizer-api/service"
+	"plate-recognizer-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type APIKeyRequest struct {
+	Name             string     `json:"name"`
+	OwnerID          uint       `json:"owner_id"`
+	AllowedLocations []string   `json:"allowed_locations"`
+	AllowedCameras   []string   `json:"allowed_cameras"` // "location/camera"
+	Scopes           []string   `json:"scopes"`
+	ExpiresAt        *time.Time `json:"expires_at"`
+}
+
+// APIKeyResponse is the API view of a key. Key is only set right after
+// creation or rotation.
+type APIKeyResponse struct {
+	ID               uint       `json:"id"`
+	Name             string     `json:"name"`
+	Prefix           string     `json:"prefix"`
+	OwnerID          uint       `json:"owner_id"`
+	AllowedLocations []string   `json:"allowed_locations"`
+	AllowedCameras   []string   `json:"allowed_cameras"`
+	Scopes           []string   `json:"scopes"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt       *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	Key              string     `json:"key,omitempty"`
+}
+
+func toAPIKeyResponse(k *model.APIKey, plaintext string) APIKeyResponse {
+	return APIKeyResponse{
+		ID:               k.ID,
+		Name:             k.Name,
+		Prefix:           k.Prefix,
+		OwnerID:          k.OwnerID,
+		AllowedLocations: model.SplitList(k.AllowedLocations),
+		AllowedCameras:   model.SplitList(k.AllowedCameras),
+		Scopes:           model.SplitList(k.Scopes),
+		ExpiresAt:        k.ExpiresAt,
+		LastUsedAt:       k.LastUsedAt,
+		RevokedAt:        k.RevokedAt,
+		CreatedAt:        k.CreatedAt,
+		Key:              plaintext,
+	}
+}
+
+type APIKeyHandler struct {
+	Service *service.APIKeyService
+}
+
+func NewAPIKeyHandler(svc *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{Service: svc}
+}
+
+func (h *APIKeyHandler) List(c *fiber.Ctx) error {
+	keys, err := h.Service.List(c.UserContext())
+	if err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+
+	items := make([]APIKeyResponse, 0, len(keys))
+	for i := range keys {
+		items = append(items, toAPIKeyResponse(&keys[i], ""))
+	}
+
+	return utils.Success(c, fiber.StatusOK, "api keys", items)
+}
+
+// Create returns the key value once, it can't be retrieved later.
+func (h *APIKeyHandler) Create(c *fiber.Ctx) error {
+	var req APIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "invalid request")
+	}
+
+	// Keys belong to the caller unless an owner is given
+	if req.OwnerID == 0 {
+		req.OwnerID, _ = c.Locals("user_id").(uint)
+	}
+
+	key, plaintext, err := h.Service.Create(c.UserContext(), service.APIKeyInput{
+		Name:             req.Name,
+		OwnerID:          req.OwnerID,
+		AllowedLocations: req.AllowedLocations,
+		AllowedCameras:   req.AllowedCameras,
+		Scopes:           req.Scopes,
+		ExpiresAt:        req.ExpiresAt,
+	})
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", err.Error())
+	}
+
+	return utils.Success(c, fiber.StatusCreated, "api key created", toAPIKeyResponse(key, plaintext))
+}
+
+func (h *APIKeyHandler) Rotate(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "invalid api key id")
+	}
+
+	key, plaintext, err := h.Service.Rotate(c.UserContext(), uint(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return utils.Error(c, fiber.StatusNotFound, "NOT_FOUND", "api key not found")
+		case errors.Is(err, service.ErrInvalidAPIKey):
+			return utils.Error(c, fiber.StatusConflict, "CONFLICT", "api key is revoked")
+		}
+		return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+
+	return utils.Success(c, fiber.StatusOK, "api key rotated", toAPIKeyResponse(key, plaintext))
+}
+
+func (h *APIKeyHandler) Revoke(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "invalid api key id")
+	}
+
+	if err := h.Service.Revoke(c.UserContext(), uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.Error(c, fiber.StatusNotFound, "NOT_FOUND", "api key not found")
+		}
+		return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+
+	return utils.Success(c, fiber.StatusOK, "api key revoked", nil)
+}
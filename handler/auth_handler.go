@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"errors"
+
+	"plate-recognizer-api/middleware"
+	"plate-recognizer-api/model"
+	"plate-recognizer-api/service"
+	"plate-recognizer-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type LoginRequest struct {
+	Username string `json:"username" form:"username"`
+	Password string `json:"password" form:"password"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" form:"refresh_token"`
+}
+
+type AuthHandler struct {
+	Service *service.AuthService
+}
+
+func NewAuthHandler(svc *service.AuthService) *AuthHandler {
+	return &AuthHandler{Service: svc}
+}
+
+func (h *AuthHandler) Login(c *fiber.Ctx) error {
+	var req LoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "invalid request")
+	}
+	if req.Username == "" || req.Password == "" {
+		return utils.Error(c, fiber.StatusBadRequest, "INVALID_REQUEST", "username and password are required")
+	}
+
+	tokens, err := h.Service.Login(c.UserContext(), req.Username, req.Password, service.ClientInfo{
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		IP:        c.IP(),
+	})
+	if err != nil {
+		return tokenError(c, err)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "login successful", tokens)
+}
+
+func (h *AuthHandler) Refresh(c *fiber.Ctx) error {
+	var req RefreshRequest
+	if err := c.BodyParser(&req); err != nil || req.RefreshToken == "" {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "refresh_token is required")
+	}
+
+	tokens, err := h.Service.Refresh(c.UserContext(), req.RefreshToken)
+	if err != nil {
+		return tokenError(c, err)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "token refreshed", tokens)
+}
+
+// Logout revokes the current session, or every session of the user with
+// all=true.
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	if _, ok := c.Locals("api_key").(*model.APIKey); ok {
+		return utils.Error(c, fiber.StatusForbidden, "FORBIDDEN", "api keys have no session to log out")
+	}
+
+	var err error
+	if c.QueryBool("all") {
+		userID, _ := c.Locals("user_id").(uint)
+		err = h.Service.RevokeUser(c.UserContext(), userID)
+	} else {
+		sessionID, ok := c.Locals("session_id").(uint)
+		if !ok {
+			return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "logout requires a bearer token")
+		}
+		err = h.Service.Revoke(c.UserContext(), sessionID)
+	}
+	if err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+
+	return utils.Success(c, fiber.StatusOK, "logged out", nil)
+}
+
+func tokenError(c *fiber.Ctx, err error) error {
+	var blocked *service.LoginBlockedError
+	switch {
+	case errors.As(err, &blocked):
+		return middleware.LoginBlocked(c, blocked)
+	case errors.Is(err, service.ErrUserInactive):
+		return utils.Error(c, fiber.StatusForbidden, "FORBIDDEN", err.Error())
+	case errors.Is(err, service.ErrInvalidCredentials),
+		errors.Is(err, service.ErrInvalidToken),
+		errors.Is(err, service.ErrSessionRevoked):
+		return utils.Error(c, fiber.StatusUnauthorized, "UNAUTHORIZED", err.Error())
+	default:
+		return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+}
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"errors"
+	"slices"
+
+	"plate-recognizer-api/model"
+	"plate-recognizer-api/service"
+	"plate-recognizer-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type LocationRequest struct {
+	Code   string                    `json:"code"`
+	Name   string                    `json:"name"`
+	Engine model.RecognitionSettings `json:"engine"`
+	Active *bool                     `json:"active"`
+}
+
+type CameraRequest struct {
+	CameraID   string                    `json:"camera_id"`
+	Name       string                    `json:"name"`
+	Direction  string                    `json:"direction"`
+	Lane       string                    `json:"lane"`
+	Engine     model.RecognitionSettings `json:"engine"`
+	DefaultMMC string                    `json:"default_mmc"`
+	Active     *bool                     `json:"active"`
+}
+
+func (r CameraRequest) input() service.CameraInput {
+	return service.CameraInput{
+		Name:       r.Name,
+		Direction:  r.Direction,
+		Lane:       r.Lane,
+		Engine:     r.Engine,
+		DefaultMMC: r.DefaultMMC,
+		Active:     r.Active,
+	}
+}
+
+type CameraHandler struct {
+	Service *service.CameraService
+}
+
+func NewCameraHandler(svc *service.CameraService) *CameraHandler {
+	return &CameraHandler{Service: svc}
+}
+
+// registryError maps registry errors to API errors.
+func registryError(c *fiber.Ctx, err error, notFound string) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return utils.Error(c, fiber.StatusNotFound, "NOT_FOUND", notFound)
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return utils.Error(c, fiber.StatusConflict, "CONFLICT", "already registered")
+	case errors.Is(err, service.ErrLocationInUse):
+		return utils.Error(c, fiber.StatusConflict, "CONFLICT", err.Error())
+	case errors.Is(err, service.ErrUnknownLocation):
+		return utils.Error(c, fiber.StatusNotFound, "NOT_FOUND", err.Error())
+	default:
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", err.Error())
+	}
+}
+
+// --- Locations ---
+
+func (h *CameraHandler) ListLocations(c *fiber.Ctx) error {
+	locations, err := h.Service.ListLocations(c.UserContext())
+	if err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+	locations = slices.DeleteFunc(locations, func(l model.Location) bool {
+		return !inLocationScope(c, l.Code)
+	})
+
+	return utils.Success(c, fiber.StatusOK, "locations", locations)
+}
+
+func (h *CameraHandler) GetLocation(c *fiber.Ctx) error {
+	location, err := h.Service.GetLocation(c.UserContext(), c.Params("code"))
+	if err == nil && !inLocationScope(c, location.Code) {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		return registryError(c, err, "location not found")
+	}
+
+	return utils.Success(c, fiber.StatusOK, "location", location)
+}
+
+func (h *CameraHandler) CreateLocation(c *fiber.Ctx) error {
+	var req LocationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "invalid request")
+	}
+
+	location, err := h.Service.CreateLocation(c.UserContext(), req.Code, service.LocationInput{
+		Name:   req.Name,
+		Engine: req.Engine,
+		Active: req.Active,
+	})
+	if err != nil {
+		return registryError(c, err, "location not found")
+	}
+
+	return utils.Success(c, fiber.StatusCreated, "location created", location)
+}
+
+func (h *CameraHandler) UpdateLocation(c *fiber.Ctx) error {
+	var req LocationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "invalid request")
+	}
+
+	location, err := h.Service.UpdateLocation(c.UserContext(), c.Params("code"), service.LocationInput{
+		Name:   req.Name,
+		Engine: req.Engine,
+		Active: req.Active,
+	})
+	if err != nil {
+		return registryError(c, err, "location not found")
+	}
+
+	return utils.Success(c, fiber.StatusOK, "location updated", location)
+}
+
+func (h *CameraHandler) DeleteLocation(c *fiber.Ctx) error {
+	if err := h.Service.DeleteLocation(c.UserContext(), c.Params("code")); err != nil {
+		return registryError(c, err, "location not found")
+	}
+
+	return utils.Success(c, fiber.StatusOK, "location removed", nil)
+}
+
+// --- Cameras ---
+
+// ListCameras lists every camera, or those of one location_code.
+func (h *CameraHandler) ListCameras(c *fiber.Ctx) error {
+	locationCode := c.Params("code", c.Query("location_code"))
+
+	cameras, err := h.Service.ListCameras(c.UserContext(), locationCode)
+	if err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+	cameras = slices.DeleteFunc(cameras, func(cam model.Camera) bool {
+		return !inLocationScope(c, cam.LocationCode)
+	})
+
+	return utils.Success(c, fiber.StatusOK, "cameras", cameras)
+}
+
+func (h *CameraHandler) GetCamera(c *fiber.Ctx) error {
+	camera, err := h.Service.GetCamera(c.UserContext(), c.Params("code"), c.Params("camera_id"))
+	if err == nil && !inLocationScope(c, camera.LocationCode) {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		return registryError(c, err, "camera not found")
+	}
+
+	return utils.Success(c, fiber.StatusOK, "camera", camera)
+}
+
+func (h *CameraHandler) CreateCamera(c *fiber.Ctx) error {
+	var req CameraRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "invalid request")
+	}
+
+	camera, err := h.Service.CreateCamera(c.UserContext(), c.Params("code"), req.CameraID, req.input())
+	if err != nil {
+		return registryError(c, err, "camera not found")
+	}
+
+	return utils.Success(c, fiber.StatusCreated, "camera created", camera)
+}
+
+func (h *CameraHandler) UpdateCamera(c *fiber.Ctx) error {
+	var req CameraRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "invalid request")
+	}
+
+	camera, err := h.Service.UpdateCamera(c.UserContext(), c.Params("code"), c.Params("camera_id"), req.input())
+	if err != nil {
+		return registryError(c, err, "camera not found")
+	}
+
+	return utils.Success(c, fiber.StatusOK, "camera updated", camera)
+}
+
+func (h *CameraHandler) DeleteCamera(c *fiber.Ctx) error {
+	if err := h.Service.DeleteCamera(c.UserContext(), c.Params("code"), c.Params("camera_id")); err != nil {
+		return registryError(c, err, "camera not found")
+	}
+
+	return utils.Success(c, fiber.StatusOK, "camera removed", nil)
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"errors"
+
+	"plate-recognizer-api/service"
+	"plate-recognizer-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// LoginGuardHandler serves the lockouts and audit trail of the login
+// protection.
+type LoginGuardHandler struct {
+	Guard *service.LoginGuard
+}
+
+func NewLoginGuardHandler(guard *service.LoginGuard) *LoginGuardHandler {
+	return &LoginGuardHandler{Guard: guard}
+}
+
+// Lockouts lists the usernames and IPs that are locked or throttled.
+func (h *LoginGuardHandler) Lockouts(c *fiber.Ctx) error {
+	throttles, err := h.Guard.Throttles(c.UserContext())
+	if err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+
+	return utils.Success(c, fiber.StatusOK, "lockouts", throttles)
+}
+
+// Unlock clears one listed lockout.
+func (h *LoginGuardHandler) Unlock(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "invalid lockout id")
+	}
+
+	actor, _ := c.Locals("username").(string)
+	if err := h.Guard.UnlockByID(c.UserContext(), uint(id), actor); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.Error(c, fiber.StatusNotFound, "NOT_FOUND", "lockout not found")
+		}
+		return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+
+	return utils.Success(c, fiber.StatusOK, "lockout cleared", nil)
+}
+
+// Events lists the login audit trail, newest first. It filters on
+// event, username and ip.
+func (h *LoginGuardHandler) Events(c *fiber.Ctx) error {
+	events, err := h.Guard.Events(c.UserContext(), service.AuthEventFilter{
+		Event:    c.Query("event"),
+		Username: c.Query("username"),
+		IP:       c.Query("ip"),
+		Limit:    c.QueryInt("limit", service.DefaultAuthEventLimit),
+	})
+	if err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+
+	return utils.Success(c, fiber.StatusOK, "auth events", events)
+}
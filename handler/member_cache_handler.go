@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"plate-recognizer-api/service"
+	"plate-recognizer-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type InvalidateMemberCacheRequest struct {
+	Plates []string `json:"plates"`
+	All    bool     `json:"all"`
+}
+
+// InvalidateMemberCacheHandler is the webhook the member backend calls
+// when memberships change.
+func InvalidateMemberCacheHandler(cache service.MemberCache) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req InvalidateMemberCacheRequest
+		if err := c.BodyParser(&req); err != nil {
+			return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "invalid request")
+		}
+
+		if !req.All && len(req.Plates) == 0 {
+			return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "plates or all is required")
+		}
+
+		var err error
+		if req.All {
+			err = cache.Flush(c.UserContext())
+		} else {
+			err = cache.Delete(c.UserContext(), req.Plates...)
+		}
+		if err != nil {
+			return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		}
+
+		return utils.Success(c, fiber.StatusOK, "member cache invalidated", fiber.Map{
+			"plates": req.Plates,
+			"all":    req.All,
+		})
+	}
+}
+
+// DeleteMemberCacheHandler invalidates a single plate.
+func DeleteMemberCacheHandler(cache service.MemberCache) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		plate := c.Params("plate")
+		if err := cache.Delete(c.UserContext(), plate); err != nil {
+			return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		}
+
+		return utils.Success(c, fiber.StatusOK, "member cache invalidated", fiber.Map{
+			"plates": []string{plate},
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"errors"
+
+	"plate-recognizer-api/service"
+	"plate-recognizer-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type ParkingSessionHandler struct {
+	Service *service.ParkingSessionService
+}
+
+func NewParkingSessionHandler(svc *service.ParkingSessionService) *ParkingSessionHandler {
+	return &ParkingSessionHandler{Service: svc}
+}
+
+// List returns the most recent sessions, filtered by location_code,
+// plate and status.
+func (h *ParkingSessionHandler) List(c *fiber.Ctx) error {
+	sessions, err := h.Service.List(c.UserContext(), service.ParkingSessionFilter{
+		LocationCode: c.Query("location_code"),
+		Plate:        c.Query("plate"),
+		Status:       c.Query("status"),
+		Limit:        c.QueryInt("limit"),
+		Locations:    locationScope(c),
+	})
+	if err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+
+	return utils.Success(c, fiber.StatusOK, "parking sessions", sessions)
+}
+
+// Current answers "how long has this car been inside".
+func (h *ParkingSessionHandler) Current(c *fiber.Ctx) error {
+	locationCode := c.Query("location_code")
+	plate := c.Query("plate")
+	if locationCode == "" || plate == "" {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "location_code and plate are required")
+	}
+
+	session, err := h.Service.Current(c.UserContext(), locationCode, plate)
+	if err == nil && !inLocationScope(c, locationCode) {
+		err = gorm.ErrRecordNotFound
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.Error(c, fiber.StatusNotFound, "NOT_FOUND", "vehicle is not inside")
+	}
+	if err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+
+	return utils.Success(c, fiber.StatusOK, "parking session", session)
+}
@@ -0,0 +1,396 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"plate-recognizer-api/internal/export"
+	"plate-recognizer-api/model"
+	"plate-recognizer-api/service"
+	"plate-recognizer-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type PlateLogHandler struct {
+	DB     *gorm.DB
+	Images *service.ImageURLResolver
+
+	// Runs the member lookup and sessions of reviewed reads
+	Service *service.PlateLogService
+}
+
+func NewPlateLogHandler(db *gorm.DB, images *service.ImageURLResolver) *PlateLogHandler {
+	return &PlateLogHandler{
+		DB:     db,
+		Images: images,
+	}
+}
+
+// PlateLogResponse is the API view of a plate log.
+type PlateLogResponse struct {
+	ID              uint            `json:"id"`
+	LocationCode    string          `json:"location_code"`
+	CameraID        string          `json:"camera_id"`
+	TransactionNo   string          `json:"transaction_no"`
+	Plate           string          `json:"plate"`
+	PlateRaw        string          `json:"plate_raw,omitempty"`
+	Accuracy        string          `json:"accuracy"`
+	Timestamp       time.Time       `json:"timestamp"`
+	ImageURL        string          `json:"image_url,omitempty"`
+	EngineURL       string          `json:"engine_url,omitempty"`
+	EngineLatencyMs int64           `json:"engine_latency_ms"`
+	EngineStatus    int             `json:"engine_status"`
+	MemberCategory  string          `json:"member_category,omitempty"`
+	MemberID        string          `json:"member_id,omitempty"`
+	MemberDegraded  bool            `json:"member_degraded,omitempty"`
+	ReviewStatus    string          `json:"review_status,omitempty"`
+	ReviewedBy      string          `json:"reviewed_by,omitempty"`
+	ReviewedAt      *time.Time      `json:"reviewed_at,omitempty"`
+	OriginalPlate   string          `json:"original_plate,omitempty"`
+	CreatedAt       time.Time       `json:"created_at"`
+	RequestData     json.RawMessage `json:"request_data,omitempty"`
+	ResponseData    json.RawMessage `json:"response_data,omitempty"`
+	ResponseFinal   json.RawMessage `json:"response_final,omitempty"`
+}
+
+// toResponse builds the API view. Raw payloads are only included when
+// detail is set, they are too heavy for listings.
+func (h *PlateLogHandler) toResponse(c *fiber.Ctx, l *model.PlateLog, detail bool) PlateLogResponse {
+	resp := PlateLogResponse{
+		ID:              l.ID,
+		LocationCode:    l.LocationCode,
+		CameraID:        l.CameraID,
+		TransactionNo:   l.TransactionNo,
+		Plate:           l.Plate,
+		PlateRaw:        l.PlateRaw,
+		Accuracy:        l.Accuracy,
+		Timestamp:       l.Timestamp,
+		EngineURL:       l.EngineURL,
+		EngineLatencyMs: l.EngineLatencyMs,
+		EngineStatus:    l.EngineStatus,
+		MemberCategory:  service.MemberCategory(l),
+		MemberID:        l.MemberID,
+		MemberDegraded:  l.MemberDegraded,
+		ReviewStatus:    l.ReviewStatus,
+		ReviewedBy:      l.ReviewedBy,
+		ReviewedAt:      l.ReviewedAt,
+		OriginalPlate:   l.OriginalPlate,
+		CreatedAt:       l.CreatedAt,
+	}
+
+	if url, err := h.Images.Resolve(c.UserContext(), l); err == nil {
+		resp.ImageURL = url
+	}
+
+	if detail {
+		resp.RequestData = rawJSON(l.RequestData)
+		resp.ResponseData = rawJSON(l.ResponseData)
+		resp.ResponseFinal = rawJSON(l.ResponseFinal)
+	}
+
+	return resp
+}
+
+func rawJSON(s string) json.RawMessage {
+	if s == "" || !json.Valid([]byte(s)) {
+		return nil
+	}
+	return json.RawMessage(s)
+}
+
+// parsePlateLogFilter reads the filter from query parameters.
+func parsePlateLogFilter(c *fiber.Ctx) (service.PlateLogFilter, error) {
+	f := service.PlateLogFilter{
+		LocationCode:  c.Query("location_code"),
+		CameraID:      c.Query("camera_id"),
+		Plate:         c.Query("plate"),
+		PlatePrefix:   c.Query("plate_prefix"),
+		TransactionNo: c.Query("transaction_no"),
+		ReviewStatus:  c.Query("review_status"),
+		Locations:     locationScope(c),
+	}
+
+	if v := c.Query("from"); v != "" {
+		t, _, err := parseQueryTime(v)
+		if err != nil {
+			return f, errors.New("from must be RFC3339 or YYYY-MM-DD")
+		}
+		f.From = &t
+	}
+
+	if v := c.Query("to"); v != "" {
+		t, dateOnly, err := parseQueryTime(v)
+		if err != nil {
+			return f, errors.New("to must be RFC3339 or YYYY-MM-DD")
+		}
+		// A bare date includes the whole day
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		f.To = &t
+	}
+
+	if v := c.Query("min_accuracy"); v != "" {
+		acc, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return f, errors.New("min_accuracy must be a number")
+		}
+		f.MinAccuracy = &acc
+	}
+
+	return f, nil
+}
+
+func parseQueryTime(v string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, false, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+	return t, true, err
+}
+
+// List returns plate logs matching the query filters, newest first.
+func (h *PlateLogHandler) List(c *fiber.Ctx) error {
+	filter, err := parsePlateLogFilter(c)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", err.Error())
+	}
+
+	page, err := service.ListPlateLogs(c.UserContext(), h.DB, service.PlateLogQuery{
+		Filter: filter,
+		Sort:   c.Query("sort"),
+		Cursor: c.Query("cursor"),
+		Limit:  c.QueryInt("limit", service.DefaultPlateLogLimit),
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) || errors.Is(err, service.ErrInvalidSort) {
+			return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", err.Error())
+		}
+		return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+
+	items := make([]PlateLogResponse, 0, len(page.Items))
+	for i := range page.Items {
+		items = append(items, h.toResponse(c, &page.Items[i], false))
+	}
+
+	return utils.Success(c, fiber.StatusOK, "plate logs", fiber.Map{
+		"items":       items,
+		"next_cursor": page.NextCursor,
+	})
+}
+
+// Get returns one plate log with its raw engine payloads.
+func (h *PlateLogHandler) Get(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "invalid plate log id")
+	}
+
+	plateLog, err := service.GetPlateLog(c.UserContext(), h.DB, uint(id))
+	if err == nil && !inLocationScope(c, plateLog.LocationCode) {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.Error(c, fiber.StatusNotFound, "NOT_FOUND", "plate log not found")
+		}
+		return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+
+	return utils.Success(c, fiber.StatusOK, "plate log", h.toResponse(c, plateLog, true))
+}
+
+// Export streams the logs matching the filters as CSV or XLSX
+// (format=csv|xlsx), oldest first.
+func (h *PlateLogHandler) Export(c *fiber.Ctx) error {
+	filter, err := parsePlateLogFilter(c)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", err.Error())
+	}
+
+	format := c.Query("format", "csv")
+	if format != "csv" && format != "xlsx" {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "format must be csv or xlsx")
+	}
+
+	filename := fmt.Sprintf("plate-logs-%s.%s", time.Now().Format("20060102-150405"), format)
+	c.Set(fiber.HeaderContentType, export.ContentType(format))
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+
+	// The body is written after the handler returns, so nothing below
+	// may touch c.
+	db := h.DB
+	images := h.Images
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx := context.Background()
+
+		out, err := export.New(format, w)
+		if err != nil {
+			log.Printf("plate log export failed: %v", err)
+			return
+		}
+
+		out.WriteRow(
+			"id", "timestamp", "location_code", "camera_id", "transaction_no",
+			"plate", "plate_raw", "accuracy", "status_member", "review_status", "image_url",
+		)
+
+		err = service.EachPlateLog(ctx, db, filter, func(l *model.PlateLog) error {
+			imageURL, _ := images.Resolve(ctx, l)
+
+			accuracy, perr := strconv.ParseFloat(l.Accuracy, 64)
+			var accuracyCell interface{} = l.Accuracy
+			if perr == nil {
+				accuracyCell = accuracy
+			}
+
+			return out.WriteRow(
+				l.ID,
+				l.Timestamp,
+				l.LocationCode,
+				l.CameraID,
+				l.TransactionNo,
+				l.Plate,
+				l.PlateRaw,
+				accuracyCell,
+				service.MemberCategory(l),
+				l.ReviewStatus,
+				imageURL,
+			)
+		})
+		if err != nil {
+			// Headers are gone already, the truncated file is all we can do
+			log.Printf("plate log export failed: %v", err)
+		}
+
+		if err := out.Close(); err != nil {
+			log.Printf("plate log export failed: %v", err)
+		}
+	})
+
+	return nil
+}
+
+// ImageURL returns a short-lived URL for the evidence image of a log.
+func (h *PlateLogHandler) ImageURL(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "invalid plate log id")
+	}
+
+	plateLog, err := service.GetPlateLog(c.UserContext(), h.DB, uint(id))
+	if err == nil && !inLocationScope(c, plateLog.LocationCode) {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.Error(c, fiber.StatusNotFound, "NOT_FOUND", "plate log not found")
+		}
+		return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+
+	url, err := h.Images.Resolve(c.UserContext(), plateLog)
+	if err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+	if url == "" {
+		return utils.Error(c, fiber.StatusNotFound, "NOT_FOUND", "image not available")
+	}
+
+	data := fiber.Map{"image_url": url}
+	if plateLog.ImageKey != "" && h.Images != nil {
+		data["expires_at"] = time.Now().Add(h.Images.Expiry)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "image url generated", data)
+}
+
+type ReviewRequest struct {
+	Plate string `json:"plate"`
+}
+
+// ReviewQueue lists the reads pending review, oldest first. It takes the
+// same filters as List.
+func (h *PlateLogHandler) ReviewQueue(c *fiber.Ctx) error {
+	filter, err := parsePlateLogFilter(c)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", err.Error())
+	}
+	filter.ReviewStatus = model.ReviewPending
+
+	page, err := service.ListPlateLogs(c.UserContext(), h.DB, service.PlateLogQuery{
+		Filter: filter,
+		Sort:   c.Query("sort", "timestamp"),
+		Cursor: c.Query("cursor"),
+		Limit:  c.QueryInt("limit", service.DefaultPlateLogLimit),
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) || errors.Is(err, service.ErrInvalidSort) {
+			return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", err.Error())
+		}
+		return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+
+	items := make([]PlateLogResponse, 0, len(page.Items))
+	for i := range page.Items {
+		items = append(items, h.toResponse(c, &page.Items[i], false))
+	}
+
+	return utils.Success(c, fiber.StatusOK, "review queue", fiber.Map{
+		"items":       items,
+		"next_cursor": page.NextCursor,
+	})
+}
+
+// Review confirms a pending read, or corrects it when plate is given.
+func (h *PlateLogHandler) Review(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "invalid plate log id")
+	}
+
+	var req ReviewRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "invalid request")
+		}
+	}
+
+	// Reads outside the caller's locations look like they don't exist
+	if scope := locationScope(c); scope != nil {
+		plateLog, err := service.GetPlateLog(c.UserContext(), h.DB, uint(id))
+		if err == nil && !inLocationScope(c, plateLog.LocationCode) {
+			err = gorm.ErrRecordNotFound
+		}
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return utils.Error(c, fiber.StatusNotFound, "NOT_FOUND", "plate log not found")
+			}
+			return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		}
+	}
+
+	reviewer, _ := c.Locals("username").(string)
+
+	plateLog, err := h.Service.Review(c.UserContext(), uint(id), req.Plate, reviewer)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return utils.Error(c, fiber.StatusNotFound, "NOT_FOUND", "plate log not found")
+		case errors.Is(err, service.ErrNotPendingReview):
+			return utils.Error(c, fiber.StatusConflict, "CONFLICT", err.Error())
+		}
+		return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+
+	return utils.Success(c, fiber.StatusOK, "plate log reviewed", h.toResponse(c, plateLog, false))
+}
@@ -1,25 +1,26 @@
 package handler
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"os"
 
+	"plate-recognizer-api/model"
 	"plate-recognizer-api/service"
 	"plate-recognizer-api/utils"
 
 	"github.com/gofiber/fiber/v2"
-	"gorm.io/gorm"
 )
 
 type RecognizeHandler struct {
-	Token string
-	DB    *gorm.DB
+	Service *service.PlateLogService
+	Cameras *service.CameraService
 }
 
-func NewRecognizeHandler(token string, db *gorm.DB) *RecognizeHandler {
+func NewRecognizeHandler(svc *service.PlateLogService, cameras *service.CameraService) *RecognizeHandler {
 	return &RecognizeHandler{
-		Token: token,
-		DB:    db,
+		Service: svc,
+		Cameras: cameras,
 	}
 }
 
@@ -51,6 +52,56 @@ func (h *RecognizeHandler) Recognize(c *fiber.Ctx) error {
 		)
 	}
 
+	if !inLocationScope(c, locationCode) {
+		return utils.Error(
+			c,
+			fiber.StatusForbidden,
+			"FORBIDDEN",
+			"not allowed for this location",
+		)
+	}
+
+	if key, ok := c.Locals("api_key").(*model.APIKey); ok && !key.AllowsCamera(locationCode, cameraID) {
+		return utils.Error(
+			c,
+			fiber.StatusForbidden,
+			"FORBIDDEN",
+			"api key is not allowed for this camera",
+		)
+	}
+
+	// ==========================
+	// Resolve camera
+	// ==========================
+	gate, err := h.Cameras.Resolve(c.UserContext(), locationCode, cameraID)
+	switch {
+	case errors.Is(err, service.ErrUnknownLocation), errors.Is(err, service.ErrUnknownCamera):
+		return utils.Error(
+			c,
+			fiber.StatusBadRequest,
+			"UNKNOWN_CAMERA",
+			err.Error(),
+		)
+	case errors.Is(err, service.ErrCameraDisabled):
+		return utils.Error(
+			c,
+			fiber.StatusForbidden,
+			"CAMERA_DISABLED",
+			err.Error(),
+		)
+	case err != nil:
+		return utils.Error(
+			c,
+			fiber.StatusInternalServerError,
+			"INTERNAL_ERROR",
+			"failed to resolve camera",
+		)
+	}
+
+	if mmc == "" {
+		mmc = gate.Camera.DefaultMMC
+	}
+
 	// ==========================
 	// Save temp image
 	// ==========================
@@ -77,16 +128,30 @@ func (h *RecognizeHandler) Recognize(c *fiber.Ctx) error {
 	// ==========================
 	// CALL SERVICE (SAVE TO DB)
 	// ==========================
-	fmt.Print(transactionNo)
-	resp, err := service.RecognizeAndSavePlateLog(
-		h.DB,
-		h.Token,
-		tmp.Name(),
-		locationCode,
-		transactionNo,
-		cameraID,
-		mmc,
+	// The user context is cancelled when the gate hangs up
+	resp, err := h.Service.RecognizeAndSavePlateLog(
+		c.UserContext(),
+		service.RecognizeRequest{
+			ImagePath:     tmp.Name(),
+			LocationCode:  locationCode,
+			TransactionNo: transactionNo,
+			CameraID:      cameraID,
+			MMC:           mmc,
+			Regions:       gate.Settings.RegionList(),
+			EngineConfig:  service.NewEngineConfig(gate.Settings),
+			MinConfidence: gate.Settings.MinConfidence,
+			Country:       gate.Country(),
+			Direction:     gate.Camera.Direction,
+		},
 	)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return utils.Error(
+			c,
+			fiber.StatusGatewayTimeout,
+			"TIMEOUT",
+			"recognition did not complete in time",
+		)
+	}
 	if err != nil {
 		return utils.Error(
 			c,
@@ -99,6 +164,16 @@ func (h *RecognizeHandler) Recognize(c *fiber.Ctx) error {
 	// ==========================
 	// SUCCESS RESPONSE
 	// ==========================
+	if resp.Code != "SUCCESS" {
+		return utils.Respond(
+			c,
+			resp.Status,
+			resp.Code,
+			resp.Message,
+			resp.Data,
+		)
+	}
+
 	return utils.Success(
 		c,
 		fiber.StatusOK,
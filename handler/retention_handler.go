@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"plate-recognizer-api/service"
+	"plate-recognizer-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type RetentionPolicyRequest struct {
+	ImageDays    int `json:"image_days"`
+	MetadataDays int `json:"metadata_days"`
+}
+
+type RetentionHandler struct {
+	Service *service.RetentionService
+}
+
+func NewRetentionHandler(svc *service.RetentionService) *RetentionHandler {
+	return &RetentionHandler{Service: svc}
+}
+
+// Report is a dry run: it lists what the next purge would remove.
+func (h *RetentionHandler) Report(c *fiber.Ctx) error {
+	report, err := h.Service.Run(c.UserContext(), true)
+	if err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+
+	return utils.Success(c, fiber.StatusOK, "retention report generated", report)
+}
+
+// Purge runs the purge now. Pass dry_run=true to only get the report.
+func (h *RetentionHandler) Purge(c *fiber.Ctx) error {
+	report, err := h.Service.Run(c.UserContext(), c.QueryBool("dry_run"))
+	if err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+
+	return utils.Success(c, fiber.StatusOK, "retention purge completed", report)
+}
+
+func (h *RetentionHandler) ListPolicies(c *fiber.Ctx) error {
+	policies, err := h.Service.Policies(c.UserContext())
+	if err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+
+	return utils.Success(c, fiber.StatusOK, "retention policies", policies)
+}
+
+func (h *RetentionHandler) SetPolicy(c *fiber.Ctx) error {
+	var req RetentionPolicyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "invalid request")
+	}
+
+	policy, err := h.Service.SetPolicy(
+		c.UserContext(),
+		c.Params("location_code"),
+		req.ImageDays,
+		req.MetadataDays,
+	)
+	if err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", err.Error())
+	}
+
+	return utils.Success(c, fiber.StatusOK, "retention policy saved", policy)
+}
+
+func (h *RetentionHandler) DeletePolicy(c *fiber.Ctx) error {
+	if err := h.Service.DeletePolicy(c.UserContext(), c.Params("location_code")); err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+
+	return utils.Success(c, fiber.StatusOK, "retention policy removed", nil)
+}
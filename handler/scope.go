@@ -0,0 +1,19 @@
+package handler
+
+import (
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// locationScope returns the locations the caller is restricted to, nil
+// when it may see every location.
+func locationScope(c *fiber.Ctx) []string {
+	locations, _ := c.Locals("locations").([]string)
+	return locations
+}
+
+func inLocationScope(c *fiber.Ctx, locationCode string) bool {
+	locations := locationScope(c)
+	return locations == nil || slices.Contains(locations, locationCode)
+}
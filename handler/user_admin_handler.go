@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"errors"
+	"time"
+
+	"plate-recognizer-api/model"
+	"plate-recognizer-api/service"
+	"plate-recognizer-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type UserResponse struct {
+	ID        uint      `json:"id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	Locations []string  `json:"locations"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func toUserResponse(u *model.User) UserResponse {
+	return UserResponse{
+		ID:        u.ID,
+		Username:  u.Username,
+		Role:      u.Role,
+		Locations: model.SplitList(u.Locations),
+		IsActive:  u.IsActive,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+	}
+}
+
+type ResetPasswordRequest struct {
+	Password string `json:"password"`
+}
+
+type SetRoleRequest struct {
+	Role string `json:"role"`
+}
+
+type SetLocationsRequest struct {
+	Locations []string `json:"locations"`
+}
+
+// UserAdminHandler serves the admin-only user management endpoints.
+type UserAdminHandler struct {
+	DB   *gorm.DB
+	Auth *service.AuthService
+}
+
+func NewUserAdminHandler(db *gorm.DB, auth *service.AuthService) *UserAdminHandler {
+	return &UserAdminHandler{
+		DB:   db,
+		Auth: auth,
+	}
+}
+
+func (h *UserAdminHandler) List(c *fiber.Ctx) error {
+	users, err := service.ListUsers(c.UserContext(), h.DB)
+	if err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+
+	items := make([]UserResponse, 0, len(users))
+	for i := range users {
+		items = append(items, toUserResponse(&users[i]))
+	}
+
+	return utils.Success(c, fiber.StatusOK, "users", items)
+}
+
+// Deactivate disables a user and ends its sessions.
+func (h *UserAdminHandler) Deactivate(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "invalid user id")
+	}
+	if isSelf(c, uint(id)) {
+		return utils.Error(c, fiber.StatusConflict, "CONFLICT", "admins can't change their own account")
+	}
+
+	user, err := service.SetUserActive(c.UserContext(), h.DB, uint(id), false)
+	if err != nil {
+		return userError(c, err)
+	}
+	if err := h.Auth.RevokeUser(c.UserContext(), uint(id)); err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+
+	return utils.Success(c, fiber.StatusOK, "user deactivated", toUserResponse(user))
+}
+
+func (h *UserAdminHandler) Reactivate(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "invalid user id")
+	}
+	if isSelf(c, uint(id)) {
+		return utils.Error(c, fiber.StatusConflict, "CONFLICT", "admins can't change their own account")
+	}
+
+	user, err := service.SetUserActive(c.UserContext(), h.DB, uint(id), true)
+	if err != nil {
+		return userError(c, err)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "user reactivated", toUserResponse(user))
+}
+
+// ResetPassword sets a new password and ends the user's sessions.
+func (h *UserAdminHandler) ResetPassword(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "invalid user id")
+	}
+
+	var req ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "invalid request")
+	}
+
+	user, err := service.ResetPassword(c.UserContext(), h.DB, uint(id), req.Password)
+	if err != nil {
+		return userError(c, err)
+	}
+	if err := h.Auth.RevokeUser(c.UserContext(), uint(id)); err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+
+	return utils.Success(c, fiber.StatusOK, "password reset", toUserResponse(user))
+}
+
+func (h *UserAdminHandler) SetRole(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "invalid user id")
+	}
+	if isSelf(c, uint(id)) {
+		return utils.Error(c, fiber.StatusConflict, "CONFLICT", "admins can't change their own account")
+	}
+
+	var req SetRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "invalid request")
+	}
+
+	user, err := service.SetUserRole(c.UserContext(), h.DB, uint(id), req.Role)
+	if err != nil {
+		return userError(c, err)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "role updated", toUserResponse(user))
+}
+
+// SetLocations restricts which locations a non-admin user can see.
+func (h *UserAdminHandler) SetLocations(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "invalid user id")
+	}
+	if isSelf(c, uint(id)) {
+		return utils.Error(c, fiber.StatusConflict, "CONFLICT", "admins can't change their own account")
+	}
+
+	var req SetLocationsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "invalid request")
+	}
+
+	user, err := service.SetUserLocations(c.UserContext(), h.DB, uint(id), req.Locations)
+	if err != nil {
+		return userError(c, err)
+	}
+
+	return utils.Success(c, fiber.StatusOK, "locations updated", toUserResponse(user))
+}
+
+// Unlock clears the login lockout and failures of a user.
+func (h *UserAdminHandler) Unlock(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "invalid user id")
+	}
+	if isSelf(c, uint(id)) {
+		return utils.Error(c, fiber.StatusConflict, "CONFLICT", "admins can't change their own account")
+	}
+
+	user, err := service.GetUser(c.UserContext(), h.DB, uint(id))
+	if err != nil {
+		return userError(c, err)
+	}
+
+	actor, _ := c.Locals("username").(string)
+	err = h.Auth.Guard.Unlock(c.UserContext(), model.ThrottleUsername, user.Username, actor)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+
+	return utils.Success(c, fiber.StatusOK, "user unlocked", toUserResponse(user))
+}
+
+func (h *UserAdminHandler) Delete(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", "invalid user id")
+	}
+	if isSelf(c, uint(id)) {
+		return utils.Error(c, fiber.StatusConflict, "CONFLICT", "admins can't change their own account")
+	}
+
+	if err := service.DeleteUser(c.UserContext(), h.DB, uint(id)); err != nil {
+		return userError(c, err)
+	}
+	if err := h.Auth.RevokeUser(c.UserContext(), uint(id)); err != nil {
+		return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+
+	return utils.Success(c, fiber.StatusOK, "user deleted", nil)
+}
+
+// isSelf guards admins from locking themselves out.
+func isSelf(c *fiber.Ctx, id uint) bool {
+	self, _ := c.Locals("user_id").(uint)
+	return self == id
+}
+
+func userError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return utils.Error(c, fiber.StatusNotFound, "NOT_FOUND", "user not found")
+	case errors.Is(err, service.ErrLastAdmin):
+		return utils.Error(c, fiber.StatusConflict, "CONFLICT", err.Error())
+	}
+	return utils.Error(c, fiber.StatusBadRequest, "BAD_REQUEST", err.Error())
+}
@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"errors"
+
+	"plate-recognizer-api/model"
 	"plate-recognizer-api/service"
 
 	"github.com/gofiber/fiber/v2"
@@ -10,6 +13,7 @@ import (
 type CreateUserRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Role     string `json:"role"`
 }
 
 func CreateUserHandler(db *gorm.DB) fiber.Handler {
@@ -19,7 +23,20 @@ func CreateUserHandler(db *gorm.DB) fiber.Handler {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
 		}
 
-		user, err := service.CreateUser(db, req.Username, req.Password)
+		var user *model.User
+		var err error
+		if bootstrap, _ := c.Locals("bootstrap").(bool); bootstrap {
+			// The bootstrap token creates the first admin and nothing else
+			if req.Role != "" && req.Role != model.RoleAdmin {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "the bootstrap token only creates an admin"})
+			}
+			user, err = service.BootstrapAdmin(c.UserContext(), db, req.Username, req.Password)
+		} else {
+			user, err = service.CreateUser(db.WithContext(c.UserContext()), req.Username, req.Password, req.Role)
+		}
+		if errors.Is(err, service.ErrBootstrapClosed) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		}
 		if err != nil {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 		}
@@ -29,6 +46,7 @@ func CreateUserHandler(db *gorm.DB) fiber.Handler {
 			"user": fiber.Map{
 				"id":         user.ID,
 				"username":   user.Username,
+				"role":       user.Role,
 				"is_active":  user.IsActive,
 				"created_at": user.CreatedAt,
 				"updated_at": user.UpdatedAt,
@@ -3,8 +3,10 @@ package minio
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	minio "github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
@@ -12,6 +14,7 @@ import (
 
 type Client struct {
 	c              *minio.Client
+	presigner      *minio.Client
 	internalEP     string
 	publicEndpoint string
 	secure         bool
@@ -29,6 +32,10 @@ func New() (*Client, error) {
 	access := os.Getenv("MINIO_ACCESS_KEY")
 	secret := os.Getenv("MINIO_SECRET_KEY")
 	useSSL := strings.ToLower(os.Getenv("MINIO_USE_SSL")) == "true"
+	region := os.Getenv("MINIO_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
 
 	if internalEP == "" || publicEP == "" {
 		return nil, fmt.Errorf("MINIO_ENDPOINT or MINIO_PUBLIC_ENDPOINT missing")
@@ -37,6 +44,19 @@ func New() (*Client, error) {
 	client, err := minio.New(internalEP, &minio.Options{
 		Creds:  credentials.NewStaticV4(access, secret, ""),
 		Secure: useSSL,
+		Region: region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Presigned URLs are signed for the host that will serve them,
+	// so they need a client bound to the public endpoint. Setting the
+	// region keeps signing offline (no bucket location lookup).
+	presigner, err := minio.New(publicEP, &minio.Options{
+		Creds:  credentials.NewStaticV4(access, secret, ""),
+		Secure: useSSL,
+		Region: region,
 	})
 	if err != nil {
 		return nil, err
@@ -44,6 +64,7 @@ func New() (*Client, error) {
 
 	instance = &Client{
 		c:              client,
+		presigner:      presigner,
 		internalEP:     internalEP,
 		publicEndpoint: publicEP,
 		secure:         useSSL,
@@ -51,32 +72,54 @@ func New() (*Client, error) {
 	return instance, nil
 }
 
+// UploadOptions are stored along with the object.
+type UploadOptions struct {
+	ContentType string
+	Metadata    map[string]string
+}
+
+// UploadFile stores the file as a private object. Use PresignedGetURL
+// to hand out temporary read access.
 func (m *Client) UploadFile(
 	ctx context.Context,
 	bucket, objectName, filePath string,
-) (string, error) {
+	opts UploadOptions,
+) error {
 
 	_, err := m.c.FPutObject(
 		ctx,
 		bucket,
 		objectName,
 		filePath,
-		minio.PutObjectOptions{},
+		minio.PutObjectOptions{
+			ContentType:  opts.ContentType,
+			UserMetadata: opts.Metadata,
+		},
+	)
+	return err
+}
+
+// PresignedGetURL returns a GET URL on the public endpoint valid for expiry.
+func (m *Client) PresignedGetURL(
+	ctx context.Context,
+	bucket, objectName string,
+	expiry time.Duration,
+) (string, error) {
+	u, err := m.presigner.PresignedGetObject(
+		ctx,
+		bucket,
+		objectName,
+		expiry,
+		url.Values{},
 	)
 	if err != nil {
 		return "", err
 	}
 
-	scheme := "http"
-	if m.secure {
-		scheme = "https"
-	}
+	return u.String(), nil
+}
 
-	return fmt.Sprintf(
-		"%s://%s/%s/%s",
-		scheme,
-		m.publicEndpoint,
-		bucket,
-		objectName,
-	), nil
+// RemoveObject deletes an object. Removing a missing object is not an error.
+func (m *Client) RemoveObject(ctx context.Context, bucket, objectName string) error {
+	return m.c.RemoveObject(ctx, bucket, objectName, minio.RemoveObjectOptions{})
 }
@@ -0,0 +1,148 @@
+// Package platematch compares plate reads that may differ by OCR errors.
+package platematch
+
+import "sort"
+
+// Substituting one of these characters for the other is a typical OCR
+// error and costs less than an arbitrary substitution.
+var confusions = [][2]byte{
+	{'O', '0'}, {'D', '0'}, {'Q', '0'}, {'O', 'D'}, {'O', 'Q'},
+	{'I', '1'}, {'L', '1'}, {'I', 'L'}, {'T', '1'},
+	{'Z', '2'}, {'A', '4'}, {'S', '5'}, {'G', '6'}, {'T', '7'},
+	{'B', '8'}, {'E', 'F'}, {'M', 'N'}, {'V', 'Y'}, {'U', 'V'},
+}
+
+const confusionCost = 0.25
+
+var confusable = func() map[[2]byte]bool {
+	m := make(map[[2]byte]bool, len(confusions)*2)
+	for _, c := range confusions {
+		m[c] = true
+		m[[2]byte{c[1], c[0]}] = true
+	}
+	return m
+}()
+
+// Alternate is another reading of the same plate proposed by the engine.
+type Alternate struct {
+	Plate string
+	Score float64
+}
+
+// Match is a plate matched to a read.
+type Match struct {
+	Plate      string  `json:"plate"`
+	Similarity float64 `json:"similarity"`
+}
+
+// Distance is the edit distance between a and b where substitutions of
+// confusable characters cost confusionCost instead of 1.
+func Distance(a, b string) float64 {
+	prev := make([]float64, len(b)+1)
+	cur := make([]float64, len(b)+1)
+	for j := range prev {
+		prev[j] = float64(j)
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = float64(i)
+		for j := 1; j <= len(b); j++ {
+			sub := 0.0
+			if a[i-1] != b[j-1] {
+				sub = 1
+				if confusable[[2]byte{a[i-1], b[j-1]}] {
+					sub = confusionCost
+				}
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+sub)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
+
+// Similarity maps Distance to [0, 1], 1 being identical plates.
+func Similarity(a, b string) float64 {
+	n := max(len(a), len(b))
+	if n == 0 {
+		return 1
+	}
+	return max(0, 1-Distance(a, b)/float64(n))
+}
+
+// Matcher scores known plates against a read and its alternates.
+type Matcher struct {
+	// MinSimilarity is the score below which nothing matches
+	MinSimilarity float64
+}
+
+// Score is the similarity of plate to the read, or to one of the
+// alternates weighted by the engine's confidence in it.
+func (m *Matcher) Score(read string, alternates []Alternate, plate string) float64 {
+	best := Similarity(read, plate)
+	for _, a := range alternates {
+		if s := Similarity(a.Plate, plate) * a.Score; s > best {
+			best = s
+		}
+	}
+	return best
+}
+
+// Best returns the known plate closest to the read, if any scores at
+// least MinSimilarity.
+func (m *Matcher) Best(read string, alternates []Alternate, known []string) (Match, bool) {
+	var best Match
+	for _, plate := range known {
+		if s := m.Score(read, alternates, plate); s > best.Similarity {
+			best = Match{Plate: plate, Similarity: s}
+		}
+	}
+	return best, best.Plate != "" && best.Similarity >= m.MinSimilarity
+}
+
+// Candidates proposes up to limit plates other than read that are worth
+// looking up, best first: the engine alternates and the single OCR
+// confusion variants of the read. When valid is set, plates it rejects
+// are skipped.
+func (m *Matcher) Candidates(read string, alternates []Alternate, limit int, valid func(string) bool) []Match {
+	seen := map[string]bool{read: true}
+	var out []Match
+
+	add := func(plate string) {
+		if plate == "" || seen[plate] {
+			return
+		}
+		seen[plate] = true
+		if valid != nil && !valid(plate) {
+			return
+		}
+		if s := m.Score(read, alternates, plate); s >= m.MinSimilarity {
+			out = append(out, Match{Plate: plate, Similarity: s})
+		}
+	}
+
+	for _, a := range alternates {
+		add(a.Plate)
+	}
+	for i := 0; i < len(read); i++ {
+		for _, c := range confusions {
+			var swap byte
+			switch read[i] {
+			case c[0]:
+				swap = c[1]
+			case c[1]:
+				swap = c[0]
+			default:
+				continue
+			}
+			add(read[:i] + string(swap) + read[i+1:])
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Similarity > out[j].Similarity })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
@@ -0,0 +1,107 @@
+package platematch
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"B1234XYZ", "B1234XYZ", 0},
+		{"B1234XYZ", "81234XYZ", confusionCost},
+		{"81234XYZ", "B1234XYZ", confusionCost},
+		{"B1234XYZ", "B1234XY", 1},
+		{"B1234XYZ", "C1234XYZ", 1},
+		{"B1O34XYZ", "B1034XYZ", confusionCost},
+		{"", "AB", 2},
+		{"", "", 0},
+	}
+
+	for _, tt := range tests {
+		if got := Distance(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Distance(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"", "", 1},
+		{"B1234", "B1234", 1},
+		{"B1234", "81234", 0.95},
+		{"B1234", "C1234", 0.8},
+		{"AB", "XYZ", 0},
+	}
+
+	for _, tt := range tests {
+		if got := Similarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestMatcherBest(t *testing.T) {
+	m := &Matcher{MinSimilarity: 0.9}
+	known := []string{"D5678AB", "B1234XYZ", "B1234XYA"}
+
+	match, ok := m.Best("81234XYZ", nil, known)
+	if !ok || match.Plate != "B1234XYZ" {
+		t.Fatalf("Best = %+v, %v, want B1234XYZ", match, ok)
+	}
+
+	if match, ok := m.Best("F9999QQ", nil, known); ok {
+		t.Errorf("Best of an unknown plate = %+v, want no match", match)
+	}
+
+	// An alternate counts, weighted by the engine's confidence in it
+	alternates := []Alternate{{Plate: "D5678AB", Score: 0.95}}
+	match, ok = m.Best("K5678AB", alternates, known)
+	if !ok || match.Plate != "D5678AB" || math.Abs(match.Similarity-0.95) > 1e-9 {
+		t.Errorf("Best with alternate = %+v, %v, want D5678AB at 0.95", match, ok)
+	}
+
+	if match, ok := m.Best("B1234XYZ", nil, nil); ok {
+		t.Errorf("Best without known plates = %+v, want no match", match)
+	}
+}
+
+func TestMatcherCandidates(t *testing.T) {
+	m := &Matcher{MinSimilarity: 0.8}
+	alternates := []Alternate{
+		{Plate: "B1234XYZ", Score: 0.9},
+		{Plate: "81234XYZ", Score: 0.9},
+	}
+
+	got := m.Candidates("81234XYZ", alternates, 3, nil)
+	if len(got) != 3 {
+		t.Fatalf("Candidates = %+v, want 3", got)
+	}
+	if got[0].Plate != "B1234XYZ" {
+		t.Errorf("best candidate = %s, want B1234XYZ", got[0].Plate)
+	}
+	for i, c := range got {
+		if c.Plate == "81234XYZ" {
+			t.Errorf("candidate %d is the read itself", i)
+		}
+		if i > 0 && c.Similarity > got[i-1].Similarity {
+			t.Errorf("candidates not ordered by similarity: %+v", got)
+		}
+	}
+
+	onlyB := func(p string) bool { return p[0] == 'B' }
+	valid := m.Candidates("81234XYZ", nil, 10, onlyB)
+	if len(valid) == 0 {
+		t.Fatal("no candidate passed valid")
+	}
+	for _, c := range valid {
+		if c.Plate[0] != 'B' {
+			t.Errorf("candidate %s rejected by valid was returned", c.Plate)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package platenorm
+
+import "math"
+
+// Indonesian plates are a region prefix of 1-2 letters, a number of 1-4
+// digits not starting with 0, and a suffix of up to 3 letters, e.g.
+// "B 1234 XYZ".
+
+var indonesianRegions = map[string]bool{
+	// Java and Bali
+	"A": true, "B": true, "D": true, "E": true, "F": true, "G": true,
+	"H": true, "K": true, "L": true, "M": true, "N": true, "P": true,
+	"R": true, "S": true, "T": true, "W": true, "Z": true,
+	"AA": true, "AB": true, "AD": true, "AE": true, "AG": true, "DK": true,
+	// Sumatra
+	"BA": true, "BB": true, "BD": true, "BE": true, "BG": true, "BH": true,
+	"BK": true, "BL": true, "BM": true, "BN": true, "BP": true,
+	// Kalimantan
+	"DA": true, "KB": true, "KH": true, "KT": true, "KU": true,
+	// Sulawesi
+	"DB": true, "DC": true, "DD": true, "DL": true, "DM": true, "DN": true,
+	"DP": true, "DT": true, "DW": true,
+	// Nusa Tenggara, Maluku and Papua
+	"DE": true, "DG": true, "DH": true, "DR": true, "EA": true, "EB": true,
+	"ED": true, "PA": true, "PB": true, "DS": true,
+	// Government and diplomatic
+	"RI": true, "CD": true, "CC": true,
+}
+
+// OCR confusions, applied only where the format expects the other kind
+var (
+	digitToLetter = map[byte]byte{
+		'0': 'O', '1': 'I', '2': 'Z', '4': 'A', '5': 'S', '6': 'G', '8': 'B',
+	}
+	letterToDigit = map[byte]byte{
+		'O': '0', 'D': '0', 'Q': '0', 'I': '1', 'L': '1', 'Z': '2',
+		'A': '4', 'S': '5', 'G': '6', 'T': '7', 'B': '8',
+	}
+)
+
+type indonesia struct{}
+
+func (indonesia) Normalize(raw string) Result {
+	cleaned := Clean(raw)
+	res := Result{
+		Raw:        raw,
+		Normalized: cleaned,
+		Formatted:  cleaned,
+		Country:    "id",
+	}
+
+	best := math.MaxInt
+	for p := 1; p <= 2; p++ {
+		for q := 0; q <= 3; q++ {
+			n := len(cleaned) - p - q
+			if n < 1 || n > 4 {
+				continue
+			}
+
+			prefix, ok1, c1 := coerce(cleaned[:p], isLetter, digitToLetter)
+			number, ok2, c2 := coerce(cleaned[p:p+n], isDigit, letterToDigit)
+			suffix, ok3, c3 := coerce(cleaned[p+n:], isLetter, digitToLetter)
+			if !ok1 || !ok2 || !ok3 || number[0] == '0' || !indonesianRegions[prefix] {
+				continue
+			}
+
+			// Prefer the split needing the fewest corrections
+			if cost := c1 + c2 + c3; cost < best {
+				best = cost
+				res.Normalized = prefix + number + suffix
+				res.Formatted = prefix + " " + number
+				if suffix != "" {
+					res.Formatted += " " + suffix
+				}
+				res.Valid = true
+				res.Corrections = cost
+			}
+		}
+	}
+
+	return res
+}
+
+// coerce makes every character of s satisfy want, using the confusion
+// table for the ones that don't.
+func coerce(s string, want func(byte) bool, table map[byte]byte) (string, bool, int) {
+	out := []byte(s)
+	changed := 0
+	for i := range out {
+		if want(out[i]) {
+			continue
+		}
+		r, ok := table[out[i]]
+		if !ok {
+			return "", false, 0
+		}
+		out[i] = r
+		changed++
+	}
+	return string(out), true, changed
+}
+
+func isLetter(c byte) bool { return c >= 'A' && c <= 'Z' }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
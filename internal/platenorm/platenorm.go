@@ -0,0 +1,68 @@
+// Package platenorm turns engine reads into canonical plate strings.
+package platenorm
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Result is a normalized read. Normalized has no separators, which is
+// the form used for storage, member lookups and session matching.
+type Result struct {
+	Raw        string `json:"raw"`
+	Normalized string `json:"normalized"`
+	Formatted  string `json:"formatted,omitempty"`
+	Valid      bool   `json:"valid"`
+	Country    string `json:"country"`
+
+	// Corrections counts characters changed to fit the plate format
+	Corrections int `json:"corrections"`
+}
+
+// Rules normalizes plates of one country.
+type Rules interface {
+	Normalize(raw string) Result
+}
+
+// For returns the rules of a country code such as "id" or "id-jk".
+// Countries without specific rules only get Clean.
+func For(country string) Rules {
+	country = strings.ToLower(strings.TrimSpace(country))
+	if i := strings.IndexByte(country, '-'); i >= 0 {
+		country = country[:i]
+	}
+
+	switch country {
+	case "id":
+		return indonesia{}
+	default:
+		return generic{country: country}
+	}
+}
+
+// Clean uppercases s and drops everything but letters and digits.
+func Clean(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range strings.ToUpper(s) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+type generic struct {
+	country string
+}
+
+func (g generic) Normalize(raw string) Result {
+	normalized := Clean(raw)
+	return Result{
+		Raw:        raw,
+		Normalized: normalized,
+		Formatted:  normalized,
+		Valid:      normalized != "",
+		Country:    g.country,
+	}
+}
@@ -0,0 +1,69 @@
+package platenorm
+
+import "testing"
+
+func TestIndonesiaNormalize(t *testing.T) {
+	tests := []struct {
+		raw         string
+		normalized  string
+		formatted   string
+		valid       bool
+		corrections int
+	}{
+		{"b 1234 xyz", "B1234XYZ", "B 1234 XYZ", true, 0},
+		{"B-1234", "B1234", "B 1234", true, 0},
+		{"DK 1 AB", "DK1AB", "DK 1 AB", true, 0},
+		{"8 1234 XYZ", "B1234XYZ", "B 1234 XYZ", true, 1},
+		{"B 12O4 XYZ", "B1204XYZ", "B 1204 XYZ", true, 1},
+		{"B 1234 X2Z", "B1234XZZ", "B 1234 XZZ", true, 1},
+		{"XX 1234", "XX1234", "XX1234", false, 0},
+		{"B 0123 X", "B0123X", "B0123X", false, 0},
+		{"B 12345 XYZ", "B12345XYZ", "B12345XYZ", false, 0},
+		{"", "", "", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got := For("id").Normalize(tt.raw)
+			if got.Raw != tt.raw || got.Country != "id" {
+				t.Errorf("raw, country = %q, %q, want %q, id", got.Raw, got.Country, tt.raw)
+			}
+			if got.Normalized != tt.normalized || got.Formatted != tt.formatted {
+				t.Errorf("normalized, formatted = %q, %q, want %q, %q", got.Normalized, got.Formatted, tt.normalized, tt.formatted)
+			}
+			if got.Valid != tt.valid || got.Corrections != tt.corrections {
+				t.Errorf("valid, corrections = %v, %d, want %v, %d", got.Valid, got.Corrections, tt.valid, tt.corrections)
+			}
+		})
+	}
+}
+
+func TestFor(t *testing.T) {
+	if got := For(" ID-JK ").Normalize("b1234xyz"); got.Formatted != "B 1234 XYZ" {
+		t.Errorf("id-jk formatted = %q, want Indonesian rules", got.Formatted)
+	}
+
+	got := For("us").Normalize("abc-123")
+	want := Result{Raw: "abc-123", Normalized: "ABC123", Formatted: "ABC123", Valid: true, Country: "us"}
+	if got != want {
+		t.Errorf("us = %+v, want %+v", got, want)
+	}
+
+	if got := For("").Normalize(" - "); got.Valid {
+		t.Errorf("empty read is valid: %+v", got)
+	}
+}
+
+func TestClean(t *testing.T) {
+	tests := map[string]string{
+		"b 1234-xyz": "B1234XYZ",
+		"B.12_34\t":  "B1234",
+		"ü1é2":       "12",
+		"":           "",
+	}
+	for in, want := range tests {
+		if got := Clean(in); got != want {
+			t.Errorf("Clean(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,381 @@
+package readerpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Strategy decides which available endpoint serves the next request.
+type Strategy string
+
+const (
+	RoundRobin    Strategy = "round_robin"
+	LeastInFlight Strategy = "least_in_flight"
+	Weighted      Strategy = "weighted"
+)
+
+var ErrNoEndpoint = errors.New("no healthy plate-recognizer available")
+
+// EndpointConfig describes one plate-reader container.
+type EndpointConfig struct {
+	URL    string
+	Weight int
+}
+
+// Config controls selection, probing and circuit breaking.
+type Config struct {
+	Endpoints []EndpointConfig
+	Strategy  Strategy
+
+	// Path appended to each endpoint for recognition requests and probes
+	ReaderPath string
+
+	ProbeInterval time.Duration
+	ProbeTimeout  time.Duration
+
+	// FailureThreshold consecutive request failures eject an endpoint
+	// for Cooldown; after that it is tried again (half-open).
+	FailureThreshold int
+	Cooldown         time.Duration
+}
+
+// ParseEndpoints parses a comma separated list of "url" or "url|weight".
+func ParseEndpoints(raw string) ([]EndpointConfig, error) {
+	var endpoints []EndpointConfig
+
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		ep := EndpointConfig{URL: item, Weight: 1}
+		if u, w, ok := strings.Cut(item, "|"); ok {
+			weight, err := strconv.Atoi(strings.TrimSpace(w))
+			if err != nil || weight < 1 {
+				return nil, fmt.Errorf("invalid weight for endpoint %q", u)
+			}
+			ep.URL = strings.TrimSpace(u)
+			ep.Weight = weight
+		}
+		ep.URL = strings.TrimRight(ep.URL, "/")
+
+		endpoints = append(endpoints, ep)
+	}
+
+	if len(endpoints) == 0 {
+		return nil, errors.New("no plate-reader endpoints configured")
+	}
+
+	return endpoints, nil
+}
+
+type endpoint struct {
+	url    string
+	weight int
+
+	inFlight int64 // atomic
+
+	// guarded by Pool.mu
+	up            bool
+	failures      int
+	ejectedUntil  time.Time
+	currentWeight int
+	lastProbe     time.Time
+	lastError     string
+}
+
+func (e *endpoint) available(now time.Time) bool {
+	return e.up && !now.Before(e.ejectedUntil)
+}
+
+// Pool keeps track of plate-reader endpoints and their health.
+type Pool struct {
+	cfg       Config
+	endpoints []*endpoint
+	client    *http.Client
+
+	mu      sync.Mutex
+	counter uint64
+}
+
+func New(cfg Config) (*Pool, error) {
+	if len(cfg.Endpoints) == 0 {
+		return nil, errors.New("no plate-reader endpoints configured")
+	}
+
+	switch cfg.Strategy {
+	case "":
+		cfg.Strategy = RoundRobin
+	case RoundRobin, LeastInFlight, Weighted:
+	default:
+		return nil, fmt.Errorf("unknown plate-reader strategy %q", cfg.Strategy)
+	}
+
+	if cfg.ReaderPath == "" {
+		cfg.ReaderPath = "/v1/plate-reader/"
+	}
+	if cfg.ProbeInterval <= 0 {
+		cfg.ProbeInterval = 5 * time.Second
+	}
+	if cfg.ProbeTimeout <= 0 {
+		cfg.ProbeTimeout = 2 * time.Second
+	}
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 3
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = 30 * time.Second
+	}
+
+	p := &Pool{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.ProbeTimeout},
+	}
+
+	for _, ep := range cfg.Endpoints {
+		weight := ep.Weight
+		if weight < 1 {
+			weight = 1
+		}
+		// Endpoints start up so the first requests don't wait for a probe
+		p.endpoints = append(p.endpoints, &endpoint{
+			url:    ep.URL,
+			weight: weight,
+			up:     true,
+		})
+	}
+
+	return p, nil
+}
+
+// Start runs the background prober until ctx is cancelled.
+func (p *Pool) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(p.cfg.ProbeInterval)
+		defer ticker.Stop()
+
+		p.probeAll(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.probeAll(ctx)
+			}
+		}
+	}()
+}
+
+func (p *Pool) probeAll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, ep := range p.endpoints {
+		wg.Add(1)
+		go func(ep *endpoint) {
+			defer wg.Done()
+			err := p.probe(ctx, ep.url)
+			p.recordProbe(ep, err)
+		}(ep)
+	}
+	wg.Wait()
+}
+
+func (p *Pool) probe(ctx context.Context, base string) error {
+	// Plate Recognizer does NOT have /health
+	// Use HEAD to plate-reader endpoint
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodHead,
+		base+p.cfg.ReaderPath,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// 200 / 401 / 405 all mean "service is alive"
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("probe returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (p *Pool) recordProbe(ep *endpoint, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	ep.lastProbe = now
+
+	if err != nil {
+		if ep.up {
+			log.Printf("plate-reader %s is DOWN: %v", ep.url, err)
+		}
+		ep.up = false
+		ep.lastError = err.Error()
+		return
+	}
+
+	if !ep.up {
+		log.Printf("plate-reader %s is UP", ep.url)
+	}
+	ep.up = true
+	ep.lastError = ""
+
+	// A healthy probe after the cooldown reinstates an ejected endpoint
+	if !ep.ejectedUntil.IsZero() && !now.Before(ep.ejectedUntil) {
+		ep.ejectedUntil = time.Time{}
+		ep.failures = 0
+	}
+}
+
+// Lease is a reserved endpoint. Callers must call Release exactly once.
+type Lease struct {
+	URL string
+
+	pool *Pool
+	ep   *endpoint
+	once sync.Once
+}
+
+// Acquire picks an available endpoint according to the pool strategy.
+func (p *Pool) Acquire() (*Lease, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	var candidates []*endpoint
+	for _, ep := range p.endpoints {
+		if ep.available(now) {
+			candidates = append(candidates, ep)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, ErrNoEndpoint
+	}
+
+	var picked *endpoint
+	switch p.cfg.Strategy {
+	case LeastInFlight:
+		// Rotate the start so ties don't always land on the same endpoint
+		start := int(p.counter % uint64(len(candidates)))
+		p.counter++
+		for i := range candidates {
+			ep := candidates[(start+i)%len(candidates)]
+			if picked == nil || atomic.LoadInt64(&ep.inFlight) < atomic.LoadInt64(&picked.inFlight) {
+				picked = ep
+			}
+		}
+	case Weighted:
+		// Smooth weighted round-robin (same as nginx)
+		total := 0
+		for _, ep := range candidates {
+			ep.currentWeight += ep.weight
+			total += ep.weight
+			if picked == nil || ep.currentWeight > picked.currentWeight {
+				picked = ep
+			}
+		}
+		picked.currentWeight -= total
+	default:
+		picked = candidates[p.counter%uint64(len(candidates))]
+		p.counter++
+	}
+
+	atomic.AddInt64(&picked.inFlight, 1)
+
+	return &Lease{
+		URL:  picked.url + p.cfg.ReaderPath,
+		pool: p,
+		ep:   picked,
+	}, nil
+}
+
+// Release returns the endpoint to the pool. A non-nil err counts towards
+// the circuit breaker, except for cancellations initiated by the caller.
+func (l *Lease) Release(err error) {
+	l.once.Do(func() {
+		atomic.AddInt64(&l.ep.inFlight, -1)
+
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+
+		p := l.pool
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		if err == nil {
+			l.ep.failures = 0
+			l.ep.ejectedUntil = time.Time{}
+			return
+		}
+
+		l.ep.failures++
+		l.ep.lastError = err.Error()
+		if l.ep.failures >= p.cfg.FailureThreshold {
+			l.ep.ejectedUntil = time.Now().Add(p.cfg.Cooldown)
+			log.Printf(
+				"plate-reader %s ejected for %s after %d failures",
+				l.ep.url,
+				p.cfg.Cooldown,
+				l.ep.failures,
+			)
+		}
+	})
+}
+
+// EndpointStatus is a snapshot of one endpoint for health reporting.
+type EndpointStatus struct {
+	URL          string     `json:"url"`
+	Weight       int        `json:"weight"`
+	Up           bool       `json:"up"`
+	Ejected      bool       `json:"ejected"`
+	EjectedUntil *time.Time `json:"ejected_until,omitempty"`
+	Failures     int        `json:"failures"`
+	InFlight     int64      `json:"in_flight"`
+	LastProbe    time.Time  `json:"last_probe"`
+	LastError    string     `json:"last_error,omitempty"`
+}
+
+func (p *Pool) Status() []EndpointStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	statuses := make([]EndpointStatus, 0, len(p.endpoints))
+	for _, ep := range p.endpoints {
+		s := EndpointStatus{
+			URL:       ep.url,
+			Weight:    ep.weight,
+			Up:        ep.up,
+			Failures:  ep.failures,
+			InFlight:  atomic.LoadInt64(&ep.inFlight),
+			LastProbe: ep.lastProbe,
+			LastError: ep.lastError,
+		}
+		if now.Before(ep.ejectedUntil) {
+			until := ep.ejectedUntil
+			s.Ejected = true
+			s.EjectedUntil = &until
+		}
+		statuses = append(statuses, s)
+	}
+
+	return statuses
+}
@@ -0,0 +1,133 @@
+package readerpool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestPool(t *testing.T, urls ...string) *Pool {
+	t.Helper()
+
+	cfg := Config{FailureThreshold: 2, Cooldown: 20 * time.Millisecond}
+	for _, u := range urls {
+		cfg.Endpoints = append(cfg.Endpoints, EndpointConfig{URL: u, Weight: 1})
+	}
+	p, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func fail(t *testing.T, p *Pool, err error) {
+	t.Helper()
+
+	lease, err2 := p.Acquire()
+	if err2 != nil {
+		t.Fatal(err2)
+	}
+	lease.Release(err)
+}
+
+func TestBreakerEjectsAfterThreshold(t *testing.T) {
+	p := newTestPool(t, "http://a")
+	readerDown := errors.New("reader down")
+
+	fail(t, p, readerDown)
+	if _, err := p.Acquire(); err != nil {
+		t.Fatalf("ejected below the threshold: %v", err)
+	}
+
+	p = newTestPool(t, "http://a")
+	fail(t, p, readerDown)
+	fail(t, p, readerDown)
+	if _, err := p.Acquire(); !errors.Is(err, ErrNoEndpoint) {
+		t.Fatalf("Acquire after 2 failures = %v, want ErrNoEndpoint", err)
+	}
+
+	status := p.Status()[0]
+	if !status.Ejected || status.EjectedUntil == nil || status.Failures != 2 || status.LastError != "reader down" {
+		t.Errorf("status = %+v, want ejected after 2 failures", status)
+	}
+
+	// Half-open after the cooldown, one success closes the breaker
+	time.Sleep(30 * time.Millisecond)
+	lease, err := p.Acquire()
+	if err != nil {
+		t.Fatalf("Acquire after cooldown: %v", err)
+	}
+	lease.Release(nil)
+	if status := p.Status()[0]; status.Ejected || status.Failures != 0 {
+		t.Errorf("status after success = %+v, want closed", status)
+	}
+}
+
+func TestBreakerIgnoresCallerCancellation(t *testing.T) {
+	p := newTestPool(t, "http://a")
+
+	for range 3 {
+		fail(t, p, context.Canceled)
+	}
+	if _, err := p.Acquire(); err != nil {
+		t.Fatalf("cancelled requests ejected the endpoint: %v", err)
+	}
+}
+
+func TestBreakerSuccessResetsFailures(t *testing.T) {
+	p := newTestPool(t, "http://a")
+	readerDown := errors.New("reader down")
+
+	fail(t, p, readerDown)
+	fail(t, p, nil)
+	fail(t, p, readerDown)
+	if _, err := p.Acquire(); err != nil {
+		t.Fatalf("failures separated by a success ejected the endpoint: %v", err)
+	}
+}
+
+func TestBreakerRoutesAroundEjected(t *testing.T) {
+	p := newTestPool(t, "http://a", "http://b")
+	readerDown := errors.New("reader down")
+
+	// Round-robin alternates, fail only the leases on a
+	for range 4 {
+		lease, err := p.Acquire()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lease.URL == "http://a"+p.cfg.ReaderPath {
+			lease.Release(readerDown)
+		} else {
+			lease.Release(nil)
+		}
+	}
+
+	for range 3 {
+		lease, err := p.Acquire()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lease.URL != "http://b"+p.cfg.ReaderPath {
+			t.Errorf("lease on %s, want the healthy endpoint", lease.URL)
+		}
+		lease.Release(nil)
+	}
+}
+
+func TestLeaseReleaseOnce(t *testing.T) {
+	p := newTestPool(t, "http://a")
+
+	lease, err := p.Acquire()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lease.Release(errors.New("reader down"))
+	lease.Release(errors.New("reader down"))
+
+	status := p.Status()[0]
+	if status.Failures != 1 || status.InFlight != 0 {
+		t.Errorf("status = %+v, want one failure and nothing in flight", status)
+	}
+}
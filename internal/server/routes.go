@@ -1,8 +1,14 @@
 package server
 
 import (
+	"strings"
+	"time"
+
 	"plate-recognizer-api/handler"
+	"plate-recognizer-api/internal/readerpool"
 	"plate-recognizer-api/middleware"
+	"plate-recognizer-api/model"
+	"plate-recognizer-api/service"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -24,22 +30,178 @@ func (s *FiberServer) RegisterRoutes() {
 		return c.JSON(fiber.Map{"status": "healthy"})
 	})
 
+	// ---------------------------
+	// Authentication routes
+	// ---------------------------
+	// Every route below is authenticated, then checked against one
+	// permission of the caller's role or API key scopes
+	auth := middleware.AuthMiddleware(s.Auth)
+
+	// Reader URLs and breaker state are internal, only admins see them
+	s.App.Get("/health/plate-readers", auth, middleware.Require(model.PermCamerasManage), func(c *fiber.Ctx) error {
+		if s.Pool == nil {
+			return c.JSON(fiber.Map{"endpoints": []readerpool.EndpointStatus{}})
+		}
+		return c.JSON(fiber.Map{"endpoints": s.Pool.Status()})
+	})
+
+	authHandler := handler.NewAuthHandler(s.Auth)
+	s.App.Post("/api/auth/login", authHandler.Login)
+	s.App.Post("/api/auth/refresh", authHandler.Refresh)
+	s.App.Post("/api/auth/logout", auth, authHandler.Logout)
+
+	// ---------------------------
+	// API key routes
+	// ---------------------------
+	apiKeyHandler := handler.NewAPIKeyHandler(s.Auth.APIKeys)
+	apiKeys := s.App.Group("/api/api-keys", auth, middleware.Require(model.PermAPIKeysManage))
+	apiKeys.Get("/", apiKeyHandler.List)
+	apiKeys.Post("/", apiKeyHandler.Create)
+	apiKeys.Post("/:id/rotate", apiKeyHandler.Rotate)
+	apiKeys.Delete("/:id", apiKeyHandler.Revoke)
+
 	// ---------------------------
 	// Plate recognition route
 	// ---------------------------
-	recognizeHandler := handler.NewRecognizeHandler(
-		s.Env.PlateRecognizerToken,
+	plateLogService := service.NewPlateLogService(
 		s.DB,
+		s.Engine,
+		s.Members,
+		service.Budget{
+			Total:  s.Env.RecognizeTimeout,
+			Engine: s.Env.EngineTimeout,
+			Member: s.Env.MemberLookupTimeout,
+			DB:     s.Env.DBWriteTimeout,
+		},
 	)
+	plateLogService.MemberPolicy = service.MemberFailurePolicy{
+		Degraded:         strings.EqualFold(s.Env.MemberFailurePolicy, "degraded"),
+		FallbackCategory: s.Env.MemberDegradedCategory,
+	}
+	plateLogService.Reconciler = s.Reconciler
+	plateLogService.Uploader = s.Uploader
+	plateLogService.Sessions = s.Sessions
+	plateLogService.MinConfidence = s.Env.MinConfidence
+	plateLogService.Country = s.Env.PlateCountry
+	plateLogService.Matcher = s.Sessions.Matcher
+	plateLogService.FuzzyMemberLookups = s.Env.PlateMatchMemberLookups
+	cameraService := service.NewCameraService(s.DB)
+	plateLogService.Cameras = cameraService
+	recognizeHandler := handler.NewRecognizeHandler(plateLogService, cameraService)
 	// 🔐 Protected route
 	s.App.Post(
 		"/api/recognize",
-		middleware.AuthMiddleware(s.DB),
+		auth,
+		middleware.Require(model.PermRecognize),
+		middleware.CancelOnDisconnect(200*time.Millisecond),
 		recognizeHandler.Recognize,
 	)
 
 	// ---------------------------
-	// User registration route
+	// Plate log routes
+	// ---------------------------
+	images := &service.ImageURLResolver{Expiry: s.Env.ImageURLExpiry}
+	if s.Storage != nil {
+		images.Signer = s.Storage
+	}
+	plateLogHandler := handler.NewPlateLogHandler(s.DB, images)
+	plateLogHandler.Service = plateLogService
+	plateLogs := s.App.Group(
+		"/api/plate-logs",
+		auth,
+		middleware.Require(model.PermLogsRead),
+	)
+	plateLogs.Get("/", plateLogHandler.List)
+	plateLogs.Get("/export", plateLogHandler.Export)
+	plateLogs.Get("/:id", plateLogHandler.Get)
+	plateLogs.Get("/:id/image", plateLogHandler.ImageURL)
+
+	reviews := s.App.Group("/api/reviews", auth, middleware.Require(model.PermLogsReview))
+	reviews.Get("/", plateLogHandler.ReviewQueue)
+	reviews.Post("/:id", plateLogHandler.Review)
+
+	// ---------------------------
+	// Location and camera registry routes
+	// ---------------------------
+	cameraHandler := handler.NewCameraHandler(cameraService)
+	read := middleware.Require(model.PermCamerasRead)
+	manage := middleware.Require(model.PermCamerasManage)
+	locations := s.App.Group("/api/locations", auth)
+	locations.Get("/", read, cameraHandler.ListLocations)
+	locations.Post("/", manage, cameraHandler.CreateLocation)
+	locations.Get("/:code", read, cameraHandler.GetLocation)
+	locations.Put("/:code", manage, cameraHandler.UpdateLocation)
+	locations.Delete("/:code", manage, cameraHandler.DeleteLocation)
+	locations.Get("/:code/cameras", read, cameraHandler.ListCameras)
+	locations.Post("/:code/cameras", manage, cameraHandler.CreateCamera)
+	locations.Get("/:code/cameras/:camera_id", read, cameraHandler.GetCamera)
+	locations.Put("/:code/cameras/:camera_id", manage, cameraHandler.UpdateCamera)
+	locations.Delete("/:code/cameras/:camera_id", manage, cameraHandler.DeleteCamera)
+	s.App.Get("/api/cameras", auth, read, cameraHandler.ListCameras)
+
 	// ---------------------------
-	s.App.Post("/api/register", handler.CreateUserHandler(s.DB))
+	// Parking session routes
+	// ---------------------------
+	parkingSessionHandler := handler.NewParkingSessionHandler(s.Sessions)
+	parkingSessions := s.App.Group(
+		"/api/parking-sessions",
+		auth,
+		middleware.Require(model.PermSessionsRead),
+	)
+	parkingSessions.Get("/", parkingSessionHandler.List)
+	parkingSessions.Get("/current", parkingSessionHandler.Current)
+
+	// ---------------------------
+	// Retention routes
+	// ---------------------------
+	retentionHandler := handler.NewRetentionHandler(s.Retention)
+	retention := s.App.Group("/api/retention", auth, middleware.Require(model.PermRetentionManage))
+	retention.Get("/report", retentionHandler.Report)
+	retention.Post("/purge", retentionHandler.Purge)
+	retention.Get("/policies", retentionHandler.ListPolicies)
+	retention.Put("/policies/:location_code", retentionHandler.SetPolicy)
+	retention.Delete("/policies/:location_code", retentionHandler.DeletePolicy)
+
+	// ---------------------------
+	// Member cache invalidation (webhook)
+	// ---------------------------
+	if s.MemberCache != nil {
+		memberCache := s.App.Group(
+			"/api/members/cache",
+			middleware.WebhookSecretMiddleware(s.Env.MemberCacheWebhookSecret),
+		)
+		memberCache.Post("/invalidate", handler.InvalidateMemberCacheHandler(s.MemberCache))
+		memberCache.Delete("/:plate", handler.DeleteMemberCacheHandler(s.MemberCache))
+	}
+
+	// ---------------------------
+	// User registration and administration routes
+	// ---------------------------
+	s.App.Post(
+		"/api/register",
+		middleware.AdminOrBootstrap(s.Auth, s.Env.BootstrapToken),
+		handler.CreateUserHandler(s.DB),
+	)
+
+	userAdminHandler := handler.NewUserAdminHandler(s.DB, s.Auth)
+	users := s.App.Group(
+		"/api/users",
+		auth,
+		middleware.Require(model.PermUsersManage),
+	)
+	users.Get("/", userAdminHandler.List)
+	users.Post("/:id/deactivate", userAdminHandler.Deactivate)
+	users.Post("/:id/reactivate", userAdminHandler.Reactivate)
+	users.Put("/:id/password", userAdminHandler.ResetPassword)
+	users.Put("/:id/role", userAdminHandler.SetRole)
+	users.Put("/:id/locations", userAdminHandler.SetLocations)
+	users.Post("/:id/unlock", userAdminHandler.Unlock)
+	users.Delete("/:id", userAdminHandler.Delete)
+
+	// Not a group, its middleware would also run before login and refresh
+	loginGuardHandler := handler.NewLoginGuardHandler(s.Auth.Guard)
+	manageUsers := middleware.Require(model.PermUsersManage)
+	s.App.Get("/api/auth/lockouts", auth, manageUsers, loginGuardHandler.Lockouts)
+	s.App.Delete("/api/auth/lockouts/:id", auth, manageUsers, loginGuardHandler.Unlock)
+	s.App.Get("/api/auth/events", auth, manageUsers, loginGuardHandler.Events)
 }
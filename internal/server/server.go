@@ -1,17 +1,34 @@
 package server
 
 import (
+	"context"
 	"log"
 	"plate-recognizer-api/config"
+	"plate-recognizer-api/internal/minio"
+	"plate-recognizer-api/internal/platematch"
+	"plate-recognizer-api/internal/readerpool"
+	"plate-recognizer-api/service"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
 type FiberServer struct {
-	App *fiber.App
-	Env *config.Env
-	DB  *gorm.DB
+	App     *fiber.App
+	Env     *config.Env
+	DB      *gorm.DB
+	Engine  service.RecognitionEngine
+	Members service.MemberClient
+	Pool    *readerpool.Pool
+
+	Reconciler  *service.MemberReconciler
+	MemberCache service.MemberCache
+	Uploader    *service.ImageUploader
+	Storage     *minio.Client
+	Retention   *service.RetentionService
+	Sessions    *service.ParkingSessionService
+	Auth        *service.AuthService
 }
 
 // New creates a new FiberServer and requires db as argument
@@ -23,12 +40,152 @@ func New(env *config.Env, db *gorm.DB) *FiberServer {
 		log.Fatal("database connection is nil")
 	}
 
+	// Only the Plate Recognizer SDK runs as a pool of local containers
+	var pool *readerpool.Pool
+	switch strings.ToLower(env.RecognitionEngine) {
+	case "", "platerecognizer":
+		p, err := newPlateReaderPool(env)
+		if err != nil {
+			log.Fatalf("failed to create plate-reader pool: %v", err)
+		}
+		p.Start(context.Background())
+		pool = p
+	}
+
+	engine, err := service.NewEngine(env, pool)
+	if err != nil {
+		log.Fatalf("failed to create recognition engine: %v", err)
+	}
+
+	members, err := service.NewMemberClient(env)
+	if err != nil {
+		log.Fatalf("failed to create member client: %v", err)
+	}
+
+	// The reconciler always asks the member service directly
+	reconciler := service.NewMemberReconciler(db, members, env.MemberReconcileInterval)
+	reconciler.Start(context.Background())
+
+	memberCache, err := service.NewMemberCache(env.MemberCache, db, env.MemberCacheMaxEntries)
+	if err != nil {
+		log.Fatalf("failed to create member cache: %v", err)
+	}
+	if dbCache, ok := memberCache.(*service.DBMemberCache); ok {
+		dbCache.Start(context.Background(), env.MemberCacheTTL)
+	}
+	if memberCache != nil {
+		members = service.NewCachedMemberClient(
+			members,
+			memberCache,
+			env.MemberCacheTTL,
+			env.MemberCacheNegativeTTL,
+		)
+	}
+
+	// Image storage is optional, without a bucket images are not kept
+	var storage *minio.Client
+	var uploader *service.ImageUploader
+	if env.MinioBucket != "" {
+		log.Println("MINIO_ENDPOINT =", env.MinioEndpoint)
+		log.Println("MINIO_BUCKET_IMAGE_LPR =", env.MinioBucket)
+
+		storage, err = minio.New()
+		if err != nil {
+			log.Fatalf("failed to create MinIO client: %v", err)
+		}
+
+		keys, err := service.NewObjectKeyTemplate(env.ImageKeyTemplate)
+		if err != nil {
+			log.Fatalf("invalid MINIO_OBJECT_KEY_TEMPLATE: %v", err)
+		}
+
+		uploader, err = service.NewImageUploader(
+			db,
+			storage,
+			env.MinioBucket,
+			keys,
+			env.ImageSpoolDir,
+			env.ImageUploadWorkers,
+			env.ImageUploadTimeout,
+			env.ImageUploadMaxBackoff,
+		)
+		if err != nil {
+			log.Fatalf("failed to create image uploader: %v", err)
+		}
+		uploader.Start(context.Background())
+	}
+
+	retention := service.NewRetentionService(
+		db,
+		nil,
+		env.RetentionImageDays,
+		env.RetentionMetadataDays,
+	)
+	if storage != nil {
+		retention.Storage = storage
+	}
+	if env.RetentionEnabled {
+		retention.Start(context.Background(), env.RetentionInterval)
+	}
+
+	auth, err := service.NewAuthService(db, env.JWTSecret, env.AccessTokenTTL, env.RefreshTokenTTL)
+	if err != nil {
+		log.Fatalf("failed to create auth service: %v", err)
+	}
+	if env.JWTSecret == "" {
+		log.Println("JWT_SECRET is not set, tokens will not survive a restart")
+	}
+	auth.LegacyForm = env.AuthLegacyForm
+	auth.APIKeys = service.NewAPIKeyService(db)
+
+	guard := service.NewLoginGuard(db)
+	guard.MaxFailures = env.LoginMaxFailures
+	guard.MaxIPFailures = env.LoginIPMaxFailures
+	guard.Lockout = env.LoginLockout
+	guard.Window = env.LoginFailureWindow
+	guard.BaseDelay = env.LoginDelayBase
+	guard.MaxDelay = env.LoginDelayMax
+	if guard.Window > 0 {
+		guard.Start(context.Background(), guard.Window)
+	}
+	auth.Guard = guard
+
+	sessions := service.NewParkingSessionService(db, env.ParkingSessionDuplicateWindow)
+	sessions.Matcher = &platematch.Matcher{MinSimilarity: env.PlateMatchMinSimilarity}
+
 	server := &FiberServer{
-		App: app,
-		Env: env,
-		DB:  db, // assign DB properly
+		App:     app,
+		Env:     env,
+		DB:      db, // assign DB properly
+		Engine:  engine,
+		Members: members,
+		Pool:    pool,
+
+		Reconciler:  reconciler,
+		MemberCache: memberCache,
+		Uploader:    uploader,
+		Storage:     storage,
+		Retention:   retention,
+		Sessions:    sessions,
+		Auth:        auth,
 	}
 
 	server.RegisterRoutes()
 	return server
 }
+
+func newPlateReaderPool(env *config.Env) (*readerpool.Pool, error) {
+	endpoints, err := readerpool.ParseEndpoints(env.PlateReaderEndpoints)
+	if err != nil {
+		return nil, err
+	}
+
+	return readerpool.New(readerpool.Config{
+		Endpoints:        endpoints,
+		Strategy:         readerpool.Strategy(strings.ToLower(env.PlateReaderStrategy)),
+		ProbeInterval:    env.PlateReaderProbeInterval,
+		ProbeTimeout:     env.PlateReaderProbeTimeout,
+		FailureThreshold: env.PlateReaderFailureThreshold,
+		Cooldown:         env.PlateReaderCooldown,
+	})
+}
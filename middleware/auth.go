@@ -1,63 +1,136 @@
 package middleware
 
 import (
+	"errors"
+	"strconv"
+	"strings"
+
 	"plate-recognizer-api/model"
+	"plate-recognizer-api/service"
 	"plate-recognizer-api/utils"
 
 	"github.com/gofiber/fiber/v2"
-	"golang.org/x/crypto/bcrypt"
-	"gorm.io/gorm"
 )
 
-func AuthMiddleware(db *gorm.DB) fiber.Handler {
+// AuthMiddleware accepts "Authorization: Bearer <access token>" or an
+// "X-API-Key" header. With auth.LegacyForm set, requests without either
+// may still send username/password form fields.
+func AuthMiddleware(auth *service.AuthService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// Read from form-data
-		username := c.FormValue("username")
-		password := c.FormValue("password")
-
-		if username == "" || password == "" {
-			return utils.Error(
-				c,
-				fiber.StatusBadRequest,
-				"INVALID_REQUEST",
-				"username and password are required",
-			)
+		if ok, err := authenticate(c, auth); !ok {
+			return err
 		}
+		return c.Next()
+	}
+}
 
-		var user model.User
-		if err := db.Where("username = ?", username).First(&user).Error; err != nil {
-			return utils.Error(
-				c,
-				fiber.StatusUnauthorized,
-				"UNAUTHORIZED",
-				"invalid username or password",
-			)
+// authenticate identifies the caller and stores it in the locals. When
+// it returns false the error response has been written already.
+func authenticate(c *fiber.Ctx, auth *service.AuthService) (bool, error) {
+	if plaintext := c.Get("X-API-Key"); plaintext != "" && auth.APIKeys != nil {
+		key, err := auth.APIKeys.Authenticate(c.UserContext(), plaintext)
+		if err != nil {
+			return false, authError(c, err)
 		}
 
-		if !user.IsActive {
-			return utils.Error(
-				c,
-				fiber.StatusForbidden,
-				"FORBIDDEN",
-				"user is inactive",
-			)
+		// Not user_id, a key must not act as the user who created it
+		c.Locals("api_key", key)
+		c.Locals("api_key_owner_id", key.OwnerID)
+		c.Locals("username", key.Prefix)
+		c.Locals("role", model.RoleDevice)
+		if key.AllowedLocations != "" {
+			c.Locals("locations", model.SplitList(key.AllowedLocations))
 		}
+		return true, nil
+	}
 
-		if err := bcrypt.CompareHashAndPassword(
-			[]byte(user.Password),
-			[]byte(password),
-		); err != nil {
-			return utils.Error(
-				c,
-				fiber.StatusUnauthorized,
-				"UNAUTHORIZED",
-				"invalid username or password",
-			)
+	if token, ok := bearerToken(c); ok {
+		user, claims, err := auth.Authenticate(c.UserContext(), token)
+		if err != nil {
+			return false, authError(c, err)
 		}
 
-		// Save username to context
-		c.Locals("username", user.Username)
+		setUser(c, user)
+		c.Locals("session_id", claims.SessionID)
+		return true, nil
+	}
 
-		return c.Next()
+	if !auth.LegacyForm {
+		return false, utils.Error(
+			c,
+			fiber.StatusUnauthorized,
+			"UNAUTHORIZED",
+			"bearer token is required",
+		)
+	}
+
+	// Legacy gate controllers send credentials in form-data
+	username := c.FormValue("username")
+	password := c.FormValue("password")
+
+	if username == "" || password == "" {
+		return false, utils.Error(
+			c,
+			fiber.StatusBadRequest,
+			"INVALID_REQUEST",
+			"username and password are required",
+		)
+	}
+
+	user, err := auth.CheckCredentials(c.UserContext(), username, password, service.ClientInfo{
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		IP:        c.IP(),
+	})
+	if err != nil {
+		return false, authError(c, err)
+	}
+
+	setUser(c, user)
+	return true, nil
+}
+
+func bearerToken(c *fiber.Ctx) (string, bool) {
+	header := c.Get(fiber.HeaderAuthorization)
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
 	}
+	return strings.TrimSpace(token), true
+}
+
+func setUser(c *fiber.Ctx, user *model.User) {
+	c.Locals("user_id", user.ID)
+	c.Locals("username", user.Username)
+	c.Locals("role", user.Role)
+	if locations := user.LocationScope(); locations != nil {
+		c.Locals("locations", locations)
+	}
+}
+
+func authError(c *fiber.Ctx, err error) error {
+	var blocked *service.LoginBlockedError
+	switch {
+	case errors.As(err, &blocked):
+		return LoginBlocked(c, blocked)
+	case errors.Is(err, service.ErrUserInactive):
+		return utils.Error(c, fiber.StatusForbidden, "FORBIDDEN", err.Error())
+	case errors.Is(err, service.ErrInvalidCredentials),
+		errors.Is(err, service.ErrInvalidToken),
+		errors.Is(err, service.ErrSessionRevoked),
+		errors.Is(err, service.ErrInvalidAPIKey):
+		return utils.Error(c, fiber.StatusUnauthorized, "UNAUTHORIZED", err.Error())
+	default:
+		return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", "authentication failed")
+	}
+}
+
+// LoginBlocked answers a throttled login with 429 and Retry-After.
+func LoginBlocked(c *fiber.Ctx, blocked *service.LoginBlockedError) error {
+	code := "TOO_MANY_ATTEMPTS"
+	if errors.Is(blocked, service.ErrLoginLocked) {
+		code = "ACCOUNT_LOCKED"
+	}
+
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(blocked.RetryAfter().Seconds())))
+	return utils.Error(c, fiber.StatusTooManyRequests, code, blocked.Error())
 }
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"plate-recognizer-api/model"
+	"plate-recognizer-api/service"
+	"plate-recognizer-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminOrBootstrap lets through user managers, or requests carrying the
+// bootstrap token in the X-Bootstrap-Token header. The token only creates
+// the first admin, it is refused once an active admin exists.
+func AdminOrBootstrap(auth *service.AuthService, bootstrapToken string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if given := c.Get("X-Bootstrap-Token"); given != "" {
+			if bootstrapToken == "" ||
+				subtle.ConstantTimeCompare([]byte(given), []byte(bootstrapToken)) != 1 {
+				return utils.Error(
+					c,
+					fiber.StatusUnauthorized,
+					"UNAUTHORIZED",
+					"invalid bootstrap token",
+				)
+			}
+
+			// The handler checks again under lock, this only fails early
+			closed, err := service.HasActiveAdmin(c.UserContext(), auth.DB)
+			if err != nil {
+				return utils.Error(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			}
+			if closed {
+				return utils.Error(c, fiber.StatusForbidden, "FORBIDDEN", service.ErrBootstrapClosed.Error())
+			}
+			c.Locals("bootstrap", true)
+			return c.Next()
+		}
+
+		if ok, err := authenticate(c, auth); !ok {
+			return err
+		}
+		if !HasPermission(c, model.PermUsersManage) {
+			return utils.Error(
+				c,
+				fiber.StatusForbidden,
+				"FORBIDDEN",
+				"only admins can register users",
+			)
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CancelOnDisconnect gives the handler a user context that is cancelled
+// once the client closes its connection. fasthttp has no close
+// notification, so the connection is probed every interval while the
+// handler runs. Handlers must pass c.UserContext() on.
+func CancelOnDisconnect(interval time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithCancel(c.UserContext())
+		defer cancel()
+		c.SetUserContext(ctx)
+
+		if conn := c.Context().Conn(); conn != nil && interval > 0 {
+			done := make(chan struct{})
+			defer close(done)
+
+			go func() {
+				ticker := time.NewTicker(interval)
+				defer ticker.Stop()
+
+				for {
+					select {
+					case <-done:
+						return
+					case <-ticker.C:
+						closed, ok := connClosed(conn)
+						if !ok {
+							// Not a socket we can probe, e.g. TLS
+							return
+						}
+						if closed {
+							cancel()
+							return
+						}
+					}
+				}
+			}()
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package middleware
+
+import "net"
+
+func connClosed(conn net.Conn) (closed bool, ok bool) {
+	return false, false
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestCancelOnDisconnect(t *testing.T) {
+	cancelled := make(chan struct{})
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Get("/", CancelOnDisconnect(10*time.Millisecond), func(c *fiber.Ctx) error {
+		select {
+		case <-c.UserContext().Done():
+			close(cancelled)
+		case <-time.After(2 * time.Second):
+		}
+		return nil
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go app.Listener(ln)
+	defer app.Shutdown()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Write([]byte("GET / HTTP/1.1\r\nHost: test\r\n\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	conn.Close()
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("handler context was not cancelled after the client went away")
+	}
+}
+
+func TestCancelOnDisconnectKeepsLiveRequests(t *testing.T) {
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Get("/", CancelOnDisconnect(10*time.Millisecond), func(c *fiber.Ctx) error {
+		select {
+		case <-c.UserContext().Done():
+			return c.SendString("cancelled")
+		case <-time.After(100 * time.Millisecond):
+			return c.SendString("done")
+		}
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go app.Listener(ln)
+	defer app.Shutdown()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("GET / HTTP/1.1\r\nHost: test\r\n\r\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 512)
+	n, _ := conn.Read(buf)
+	if got := string(buf[:n]); !strings.HasSuffix(got, "done") {
+		t.Fatalf("response = %q, want the handler to finish", got)
+	}
+}
@@ -0,0 +1,39 @@
+//go:build unix
+
+package middleware
+
+import (
+	"errors"
+	"net"
+	"syscall"
+)
+
+// connClosed peeks at the socket without consuming data: a read of 0
+// bytes means the peer closed it. ok is false when conn can't be probed.
+func connClosed(conn net.Conn) (closed bool, ok bool) {
+	sc, isSyscall := conn.(syscall.Conn)
+	if !isSyscall {
+		return false, false
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return false, false
+	}
+
+	buf := make([]byte, 1)
+	err = raw.Read(func(fd uintptr) bool {
+		n, _, rerr := syscall.Recvfrom(int(fd), buf, syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+		switch {
+		case errors.Is(rerr, syscall.EAGAIN), errors.Is(rerr, syscall.EINTR):
+		case rerr != nil:
+			closed = true
+		case n == 0:
+			closed = true
+		}
+		return true
+	})
+	if err != nil {
+		return true, true
+	}
+	return closed, true
+}
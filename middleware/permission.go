@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"plate-recognizer-api/model"
+	"plate-recognizer-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Require lets through callers granted permission, by their role for
+// users and by their scopes for API keys. It must run after
+// AuthMiddleware.
+func Require(permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !HasPermission(c, permission) {
+			return utils.Error(
+				c,
+				fiber.StatusForbidden,
+				"FORBIDDEN",
+				"missing permission "+permission,
+			)
+		}
+		return c.Next()
+	}
+}
+
+// HasPermission reports whether the authenticated caller holds permission.
+func HasPermission(c *fiber.Ctx, permission string) bool {
+	if key, ok := c.Locals("api_key").(*model.APIKey); ok {
+		return key.HasScope(permission)
+	}
+
+	role, _ := c.Locals("role").(string)
+	return model.RoleHas(role, permission)
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"plate-recognizer-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// WebhookSecretMiddleware authenticates machine callbacks with a shared
+// secret sent in the X-Webhook-Secret header. An empty secret disables
+// the protected routes altogether.
+func WebhookSecretMiddleware(secret string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if secret == "" {
+			return utils.Error(
+				c,
+				fiber.StatusForbidden,
+				"FORBIDDEN",
+				"webhook secret is not configured",
+			)
+		}
+
+		given := c.Get("X-Webhook-Secret")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(secret)) != 1 {
+			return utils.Error(
+				c,
+				fiber.StatusUnauthorized,
+				"UNAUTHORIZED",
+				"invalid webhook secret",
+			)
+		}
+
+		return c.Next()
+	}
+}
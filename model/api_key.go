@@ -0,0 +1,77 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Scopes are the permissions an API key may carry.
+var Scopes = []string{PermRecognize, PermLogsRead, PermSessionsRead}
+
+// APIKey authenticates a gate device. The key is "<Prefix>.<secret>",
+// only the SHA-256 of the secret is stored. Lists are comma separated,
+// empty AllowedLocations or AllowedCameras allow everything. Camera ids
+// are only unique per location, AllowedCameras holds "location/camera"
+// pairs.
+type APIKey struct {
+	ID               uint   `gorm:"primaryKey"`
+	Name             string `gorm:"type:varchar(100)"`
+	Prefix           string `gorm:"type:varchar(20);uniqueIndex;not null"`
+	SecretHash       string `gorm:"type:varchar(64);not null"`
+	OwnerID          uint   `gorm:"index"`
+	AllowedLocations string `gorm:"type:text"`
+	AllowedCameras   string `gorm:"type:text"`
+	Scopes           string `gorm:"type:varchar(255)"`
+	ExpiresAt        *time.Time
+	LastUsedAt       *time.Time
+	RevokedAt        *time.Time
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+func (k *APIKey) HasScope(scope string) bool {
+	return containsList(k.Scopes, scope)
+}
+
+// AllowsLocation reports whether the key may act for a location.
+func (k *APIKey) AllowsLocation(locationCode string) bool {
+	return k.AllowedLocations == "" || containsList(k.AllowedLocations, locationCode)
+}
+
+// AllowsCamera reports whether the key may post reads of a camera.
+func (k *APIKey) AllowsCamera(locationCode, cameraID string) bool {
+	if !k.AllowsLocation(locationCode) {
+		return false
+	}
+	return k.AllowedCameras == "" || containsList(k.AllowedCameras, CameraRef(locationCode, cameraID))
+}
+
+// CameraRef is the "location/camera" form of a camera in AllowedCameras.
+func CameraRef(locationCode, cameraID string) string {
+	return locationCode + "/" + cameraID
+}
+
+// Active reports whether the key is neither revoked nor expired.
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// SplitList splits a comma separated column.
+func SplitList(s string) []string {
+	out := []string{}
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func containsList(list, v string) bool {
+	for _, item := range SplitList(list) {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+const (
+	AuthEventLoginSucceeded = "login_succeeded"
+	AuthEventLoginFailed    = "login_failed"
+	AuthEventLoginBlocked   = "login_blocked"
+	AuthEventLocked         = "locked"
+	AuthEventUnlocked       = "unlocked"
+)
+
+// AuthEvent is an audit entry of the login protection. Actor is the
+// admin behind an unlock, empty for client attempts.
+type AuthEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Event     string    `gorm:"type:varchar(30);index;not null" json:"event"`
+	Username  string    `gorm:"type:varchar(255);index" json:"username,omitempty"`
+	IP        string    `gorm:"type:varchar(64);index" json:"ip,omitempty"`
+	UserAgent string    `gorm:"type:varchar(255)" json:"user_agent,omitempty"`
+	Actor     string    `gorm:"type:varchar(255)" json:"actor,omitempty"`
+	Detail    string    `gorm:"type:text" json:"detail,omitempty"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
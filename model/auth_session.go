@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// AuthSession is one login. Its refresh token rotates on every refresh,
+// only the hash of the current one is stored. Revoking the session
+// invalidates both its access and refresh tokens.
+type AuthSession struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"index;not null" json:"user_id"`
+	RefreshHash string     `gorm:"type:varchar(64);not null" json:"-"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	UserAgent   string     `gorm:"type:varchar(255)" json:"user_agent"`
+	IP          string     `gorm:"type:varchar(64)" json:"ip"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// Camera is a registered gate camera. CameraID is unique per location.
+type Camera struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	LocationCode string `gorm:"type:varchar(50);uniqueIndex:idx_camera_location;not null" json:"location_code"`
+	CameraID     string `gorm:"type:varchar(50);uniqueIndex:idx_camera_location;not null" json:"camera_id"`
+	Name         string `gorm:"type:varchar(100)" json:"name"`
+
+	// Direction is "entry" or "exit" and drives parking sessions
+	Direction string `gorm:"type:varchar(10);not null" json:"direction"`
+	Lane      string `gorm:"type:varchar(20)" json:"lane"`
+
+	// Engine overrides the location's recognition settings
+	Engine RecognitionSettings `gorm:"embedded;embeddedPrefix:engine_" json:"engine"`
+	// DefaultMMC is used when the gate doesn't send mmc
+	DefaultMMC string `gorm:"type:varchar(10)" json:"default_mmc"`
+
+	Active    bool      `gorm:"default:true" json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// Location is a parking site. Its Code is the location_code sent by gates.
+type Location struct {
+	ID   uint   `gorm:"primaryKey" json:"id"`
+	Code string `gorm:"type:varchar(50);uniqueIndex;not null" json:"code"`
+	Name string `gorm:"type:varchar(100)" json:"name"`
+
+	// Engine holds the recognition settings of every camera of the site
+	Engine RecognitionSettings `gorm:"embedded;embeddedPrefix:engine_" json:"engine"`
+
+	Active    bool      `gorm:"default:true" json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+const (
+	ThrottleUsername = "username"
+	ThrottleIP       = "ip"
+)
+
+// LoginThrottle counts the recent failed logins of one username or one
+// client IP. Failures older than the failure window no longer count.
+type LoginThrottle struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	Kind          string     `gorm:"type:varchar(20);uniqueIndex:idx_login_throttle_key;not null" json:"kind"`
+	Key           string     `gorm:"type:varchar(255);uniqueIndex:idx_login_throttle_key;not null" json:"key"`
+	Failures      int        `gorm:"not null;default:0" json:"failures"`
+	LastFailureAt *time.Time `json:"last_failure_at,omitempty"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// Locked reports whether logins are refused until LockedUntil.
+func (t *LoginThrottle) Locked(now time.Time) bool {
+	return t.LockedUntil != nil && now.Before(*t.LockedUntil)
+}
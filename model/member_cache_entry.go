@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// MemberCacheEntry backs the shared member cache (MEMBER_CACHE=db).
+type MemberCacheEntry struct {
+	Plate      string `gorm:"type:varchar(20);primaryKey"`
+	Category   string `gorm:"type:varchar(50)"`
+	MemberID   string `gorm:"type:varchar(100)"`
+	ValidFrom  *time.Time
+	ValidUntil *time.Time
+	ExpiresAt  time.Time `gorm:"index"`
+	UpdatedAt  time.Time
+}
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+const (
+	ReconciliationPending  = "PENDING"
+	ReconciliationResolved = "RESOLVED"
+	ReconciliationFailed   = "FAILED" // permanent error or out of attempts
+)
+
+// MemberReconciliation is a member lookup that failed while the gate was
+// served in degraded mode, queued to be retried later.
+type MemberReconciliation struct {
+	ID               uint   `gorm:"primaryKey"`
+	PlateLogID       uint   `gorm:"index"`
+	Plate            string `gorm:"type:varchar(20);index"`
+	LocationCode     string `gorm:"type:varchar(50)"`
+	FallbackCategory string `gorm:"type:varchar(50)"`
+	Status           string `gorm:"type:varchar(20);index;default:PENDING"`
+	Attempts         int
+	LastError        string    `gorm:"type:text"`
+	Category         string    `gorm:"type:varchar(50)"`
+	MemberID         string    `gorm:"type:varchar(100)"`
+	NextAttemptAt    time.Time `gorm:"index"`
+	ResolvedAt       *time.Time
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+const (
+	SessionOpen       = "OPEN"
+	SessionClosed     = "CLOSED"
+	SessionSuperseded = "SUPERSEDED" // a later entry arrived without an exit read
+)
+
+// ParkingSession pairs the entry and exit reads of a vehicle at a location.
+type ParkingSession struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	LocationCode string `gorm:"type:varchar(50);index:idx_session_lookup" json:"location_code"`
+	Plate        string `gorm:"type:varchar(20);index:idx_session_lookup" json:"plate"`
+	Status       string `gorm:"type:varchar(20);index:idx_session_lookup" json:"status"`
+
+	EntryLogID    uint      `json:"entry_log_id"`
+	EntryCameraID string    `gorm:"type:varchar(50)" json:"entry_camera_id"`
+	EntryAt       time.Time `gorm:"index" json:"entry_at"`
+
+	ExitLogID    *uint      `json:"exit_log_id,omitempty"`
+	ExitCameraID string     `gorm:"type:varchar(50)" json:"exit_camera_id,omitempty"`
+	ExitAt       *time.Time `json:"exit_at,omitempty"`
+
+	// Set when the exit read only matched Plate approximately
+	ExitPlate       string   `gorm:"type:varchar(20)" json:"exit_plate,omitempty"`
+	MatchSimilarity *float64 `json:"match_similarity,omitempty"`
+
+	DurationSeconds int64     `json:"duration_seconds"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
@@ -0,0 +1,48 @@
+package model
+
+import "slices"
+
+// Permissions checked on API routes
+const (
+	PermRecognize       = "recognize"
+	PermLogsRead        = "logs:read"
+	PermLogsReview      = "logs:review"
+	PermSessionsRead    = "sessions:read"
+	PermCamerasRead     = "cameras:read"
+	PermCamerasManage   = "cameras:manage"
+	PermRetentionManage = "retention:manage"
+	PermAPIKeysManage   = "apikeys:manage"
+	PermUsersManage     = "users:manage"
+)
+
+// RolePermissions maps each role to what it may do. Operators may still
+// post recognitions, gate controllers predating the device role log in
+// as operators.
+var RolePermissions = map[string][]string{
+	RoleAdmin: {
+		PermRecognize,
+		PermLogsRead,
+		PermLogsReview,
+		PermSessionsRead,
+		PermCamerasRead,
+		PermCamerasManage,
+		PermRetentionManage,
+		PermAPIKeysManage,
+		PermUsersManage,
+	},
+	RoleOperator: {
+		PermRecognize,
+		PermLogsRead,
+		PermLogsReview,
+		PermSessionsRead,
+		PermCamerasRead,
+	},
+	RoleDevice: {
+		PermRecognize,
+	},
+}
+
+// RoleHas reports whether role grants permission.
+func RoleHas(role, permission string) bool {
+	return slices.Contains(RolePermissions[role], permission)
+}
@@ -2,17 +2,51 @@ package model
 
 import "time"
 
+const (
+	ReviewPending   = "PENDING"
+	ReviewConfirmed = "CONFIRMED"
+	ReviewCorrected = "CORRECTED"
+)
+
 type PlateLog struct {
 	ID            uint   `gorm:"primaryKey"`
 	LocationCode  string `gorm:"type:varchar(50);index"`
 	CameraID      string `gorm:"type:varchar(50);index"`
 	TransactionNo string `gorm:"type:varchar(100);index"`
 	Plate         string `gorm:"type:varchar(20);index"`
+	PlateRaw      string `gorm:"type:varchar(20)"` // engine read before normalization
 	Accuracy      string `gorm:"type:varchar(10)"`
 	Timestamp     time.Time
 	RequestData   string `gorm:"type:text"`
 	ResponseData  string `gorm:"type:text"`
 	ResponseFinal string `gorm:"type:text"`
 	ImageURL      string `gorm:"type:text" json:"image_url"`
-	CreatedAt     time.Time
+
+	// Private object location, served through presigned URLs.
+	// ImageURL is only set on rows stored before images went private.
+	ImageBucket string `gorm:"type:varchar(100)"`
+	ImageKey    string `gorm:"type:varchar(255)"`
+
+	// Set while the image waits in the upload spool
+	ImagePending bool `gorm:"index;not null;default:false"`
+
+	// Membership the gate was served with. MemberDegraded marks a fallback
+	// category pending reconciliation.
+	MemberCategory string `gorm:"type:varchar(50);index"`
+	MemberID       string `gorm:"type:varchar(100)"`
+	MemberDegraded bool
+
+	// Engine exchange details, kept to replay disputed reads
+	EngineURL       string `gorm:"type:varchar(255)"`
+	EngineLatencyMs int64
+	EngineStatus    int
+
+	// Review of low-confidence reads. OriginalPlate keeps the engine read
+	// when an operator corrected Plate.
+	ReviewStatus  string `gorm:"type:varchar(20);index"`
+	ReviewedBy    string `gorm:"type:varchar(50)"`
+	ReviewedAt    *time.Time
+	OriginalPlate string `gorm:"type:varchar(20)"`
+
+	CreatedAt time.Time
 }
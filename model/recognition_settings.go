@@ -0,0 +1,56 @@
+package model
+
+import "strings"
+
+// RecognitionSettings are the engine options of a location or a camera.
+// Empty fields of a camera fall back to its location, then to the engine
+// defaults.
+type RecognitionSettings struct {
+	// Regions is a comma separated list of region codes, e.g. "id"
+	Regions       string   `gorm:"type:varchar(100)" json:"regions"`
+	Threshold     *float64 `json:"threshold"`
+	Mode          string   `gorm:"type:varchar(20)" json:"mode"`
+	Region        string   `gorm:"type:varchar(20)" json:"region"`
+	DetectionRule string   `gorm:"type:varchar(20)" json:"detection_rule"`
+	DetectionMode string   `gorm:"type:varchar(20)" json:"detection_mode"`
+
+	// MinConfidence is applied by the API, reads below it go to review
+	MinConfidence *float64 `json:"min_confidence"`
+}
+
+// RegionList splits Regions.
+func (s RecognitionSettings) RegionList() []string {
+	var regions []string
+	for _, r := range strings.Split(s.Regions, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			regions = append(regions, r)
+		}
+	}
+	return regions
+}
+
+// Merge returns s with its empty fields taken from fallback.
+func (s RecognitionSettings) Merge(fallback RecognitionSettings) RecognitionSettings {
+	if s.Regions == "" {
+		s.Regions = fallback.Regions
+	}
+	if s.Threshold == nil {
+		s.Threshold = fallback.Threshold
+	}
+	if s.Mode == "" {
+		s.Mode = fallback.Mode
+	}
+	if s.Region == "" {
+		s.Region = fallback.Region
+	}
+	if s.DetectionRule == "" {
+		s.DetectionRule = fallback.DetectionRule
+	}
+	if s.DetectionMode == "" {
+		s.DetectionMode = fallback.DetectionMode
+	}
+	if s.MinConfidence == nil {
+		s.MinConfidence = fallback.MinConfidence
+	}
+	return s
+}
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// RetentionPolicy overrides the default retention for one location.
+// A value of 0 days keeps the data forever.
+type RetentionPolicy struct {
+	ID           uint   `gorm:"primaryKey"`
+	LocationCode string `gorm:"type:varchar(50);uniqueIndex;not null"`
+	ImageDays    int
+	MetadataDays int
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
@@ -6,10 +6,20 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	RoleAdmin    = "admin"
+	RoleOperator = "operator"
+	RoleDevice   = "device"
+)
+
+var Roles = []string{RoleAdmin, RoleOperator, RoleDevice}
+
 type User struct {
 	ID        uint      `gorm:"primaryKey;autoIncrement"`
 	Username  string    `gorm:"type:varchar(50);unique;not null"`
 	Password  string    `gorm:"type:varchar(255);not null"`
+	Role      string    `gorm:"type:varchar(20);not null;default:operator"`
+	Locations string    `gorm:"type:text"` // comma separated, empty for all
 	IsActive  bool      `gorm:"default:true"`
 	CreatedAt time.Time `gorm:"autoCreateTime"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime"`
@@ -30,3 +40,12 @@ func (u *User) CheckPassword(password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
 	return err == nil
 }
+
+// LocationScope returns the locations the user is restricted to, nil for
+// every location. Admins are never restricted.
+func (u *User) LocationScope() []string {
+	if u.Role == RoleAdmin || u.Locations == "" {
+		return nil
+	}
+	return SplitList(u.Locations)
+}
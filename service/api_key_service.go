@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"plate-recognizer-api/model"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidAPIKey = errors.New("invalid, revoked or expired api key")
+
+// apiKeyTouchInterval limits last_used_at writes to one per key and
+// interval, gates post many reads per minute.
+const apiKeyTouchInterval = time.Minute
+
+// APIKeyInput holds the editable fields of an API key.
+type APIKeyInput struct {
+	Name             string
+	OwnerID          uint
+	AllowedLocations []string
+	AllowedCameras   []string
+	Scopes           []string
+	ExpiresAt        *time.Time
+}
+
+// APIKeyService manages gate device keys.
+type APIKeyService struct {
+	DB *gorm.DB
+}
+
+func NewAPIKeyService(db *gorm.DB) *APIKeyService {
+	return &APIKeyService{DB: db}
+}
+
+// Create stores a new key and returns it with its plaintext value, which
+// is not kept and can't be shown again.
+func (s *APIKeyService) Create(ctx context.Context, in APIKeyInput) (*model.APIKey, string, error) {
+	if err := validateScopes(in.Scopes); err != nil {
+		return nil, "", err
+	}
+	if in.ExpiresAt != nil && in.ExpiresAt.Before(time.Now()) {
+		return nil, "", errors.New("expires_at is in the past")
+	}
+	locations, err := cameraLocations(in.AllowedLocations, in.AllowedCameras)
+	if err != nil {
+		return nil, "", err
+	}
+	in.AllowedLocations = locations
+
+	key := &model.APIKey{
+		Name:             in.Name,
+		OwnerID:          in.OwnerID,
+		AllowedLocations: joinList(in.AllowedLocations),
+		AllowedCameras:   joinList(in.AllowedCameras),
+		Scopes:           joinList(in.Scopes),
+		ExpiresAt:        in.ExpiresAt,
+	}
+
+	plaintext, err := newAPIKeySecret(key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.DB.WithContext(ctx).Create(key).Error; err != nil {
+		return nil, "", err
+	}
+	return key, plaintext, nil
+}
+
+// cameraLocations checks that cameras are "location/camera" pairs within
+// the allowed locations. Without allowed locations the key is limited to
+// the locations of its cameras, so it can't read other sites.
+func cameraLocations(locations, cameras []string) ([]string, error) {
+	derived := len(locations) == 0
+	for _, ref := range cameras {
+		if ref = strings.TrimSpace(ref); ref == "" {
+			continue
+		}
+		loc, cam, ok := strings.Cut(ref, "/")
+		if !ok || loc == "" || cam == "" {
+			return nil, fmt.Errorf("camera %q must be given as location/camera", ref)
+		}
+		if !slices.Contains(locations, loc) {
+			if !derived {
+				return nil, fmt.Errorf("camera %q is outside the allowed locations", ref)
+			}
+			locations = append(locations, loc)
+		}
+	}
+	return locations, nil
+}
+
+func (s *APIKeyService) List(ctx context.Context) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	err := s.DB.WithContext(ctx).Order("id").Find(&keys).Error
+	return keys, err
+}
+
+// Rotate replaces the secret of a key, the old value stops working.
+func (s *APIKeyService) Rotate(ctx context.Context, id uint) (*model.APIKey, string, error) {
+	var key model.APIKey
+	if err := s.DB.WithContext(ctx).First(&key, id).Error; err != nil {
+		return nil, "", err
+	}
+	if key.RevokedAt != nil {
+		return nil, "", ErrInvalidAPIKey
+	}
+
+	plaintext, err := newAPIKeySecret(&key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.DB.WithContext(ctx).
+		Model(&key).
+		Select("prefix", "secret_hash").
+		Updates(&key).Error; err != nil {
+		return nil, "", err
+	}
+	return &key, plaintext, nil
+}
+
+// Revoke disables a key for good.
+func (s *APIKeyService) Revoke(ctx context.Context, id uint) error {
+	res := s.DB.WithContext(ctx).
+		Model(&model.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Authenticate returns the active key matching a plaintext value. Keys
+// of an inactive owner are refused.
+func (s *APIKeyService) Authenticate(ctx context.Context, plaintext string) (*model.APIKey, error) {
+	prefix, secret, ok := strings.Cut(plaintext, ".")
+	if !ok || prefix == "" || secret == "" {
+		return nil, ErrInvalidAPIKey
+	}
+
+	db := s.DB.WithContext(ctx)
+
+	var key model.APIKey
+	if err := db.Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(sha256Hex(secret)), []byte(key.SecretHash)) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if !key.Active(now) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if key.OwnerID != 0 {
+		var owner model.User
+		if err := db.Select("is_active").First(&owner, key.OwnerID).Error; err != nil || !owner.IsActive {
+			return nil, ErrInvalidAPIKey
+		}
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > apiKeyTouchInterval {
+		key.LastUsedAt = &now
+		db.Model(&key).UpdateColumn("last_used_at", now)
+	}
+
+	return &key, nil
+}
+
+// newAPIKeySecret sets a fresh prefix and secret hash on key and returns
+// the plaintext key.
+func newAPIKeySecret(key *model.APIKey) (string, error) {
+	prefix := make([]byte, 6)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(prefix); err != nil {
+		return "", fmt.Errorf("generate api key: %w", err)
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("generate api key: %w", err)
+	}
+
+	key.Prefix = "lpr_" + hex.EncodeToString(prefix)
+	encoded := base64.RawURLEncoding.EncodeToString(secret)
+	key.SecretHash = sha256Hex(encoded)
+
+	return key.Prefix + "." + encoded, nil
+}
+
+func validateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return errors.New("at least one scope is required")
+	}
+	for _, scope := range scopes {
+		known := false
+		for _, s := range model.Scopes {
+			known = known || s == scope
+		}
+		if !known {
+			return fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+	return nil
+}
+
+func joinList(items []string) string {
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return strings.Join(out, ",")
+}
@@ -0,0 +1,361 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"plate-recognizer-api/model"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	tokenTypeAccess  = "access"
+	tokenTypeRefresh = "refresh"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrUserInactive       = errors.New("user is inactive")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrSessionRevoked     = errors.New("session has been revoked")
+
+	errReuseDetected = errors.New("refresh token reuse detected")
+)
+
+// TokenClaims are the claims of access and refresh tokens.
+type TokenClaims struct {
+	jwt.RegisteredClaims
+	Type      string `json:"typ"`
+	SessionID uint   `json:"sid"`
+	Username  string `json:"username"`
+}
+
+// TokenPair is returned by Login and Refresh.
+type TokenPair struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshExpiresIn int64  `json:"refresh_expires_in"`
+}
+
+// ClientInfo describes where a login comes from.
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// AuthService issues and verifies HMAC signed JWTs. Access tokens are
+// short-lived, refresh tokens rotate and are bound to an AuthSession.
+type AuthService struct {
+	DB         *gorm.DB
+	Secret     []byte
+	Issuer     string
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+
+	// LegacyForm accepts username/password form fields on protected
+	// routes, for gate controllers that can't send a bearer token.
+	LegacyForm bool
+
+	// Optional X-API-Key authentication of gate devices
+	APIKeys *APIKeyService
+
+	// Optional brute-force protection of password logins
+	Guard *LoginGuard
+}
+
+// NewAuthService returns an AuthService. Without a secret a random one
+// is generated, so tokens don't survive a restart.
+func NewAuthService(db *gorm.DB, secret string, accessTTL, refreshTTL time.Duration) (*AuthService, error) {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+
+	return &AuthService{
+		DB:         db,
+		Secret:     key,
+		Issuer:     "plate-recognizer-api",
+		AccessTTL:  accessTTL,
+		RefreshTTL: refreshTTL,
+	}, nil
+}
+
+// CheckCredentials returns the active user matching username and
+// password. With a Guard, throttled clients are refused with a
+// *LoginBlockedError before the password is checked, concurrent logins
+// of one username are checked one at a time. Device accounts are shared
+// by many gates and only throttled by IP.
+func (s *AuthService) CheckCredentials(ctx context.Context, username, password string, client ClientInfo) (*model.User, error) {
+	var user model.User
+	err := s.DB.WithContext(ctx).Where("username = ?", username).First(&user).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	found := err == nil
+	shared := found && user.Role == model.RoleDevice
+
+	// Unknown usernames count as failures too, so they can't be told apart
+	verify := func() error {
+		if !found || !user.CheckPassword(password) {
+			return ErrInvalidCredentials
+		}
+		return nil
+	}
+
+	if s.Guard != nil {
+		err = s.Guard.Attempt(ctx, username, shared, client, verify)
+	} else {
+		err = verify()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrUserInactive
+	}
+	return &user, nil
+}
+
+// Login opens a session and returns its first token pair.
+func (s *AuthService) Login(ctx context.Context, username, password string, client ClientInfo) (*TokenPair, error) {
+	user, err := s.CheckCredentials(ctx, username, password, client)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &model.AuthSession{
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(s.RefreshTTL),
+		UserAgent: truncate(client.UserAgent, 255),
+		IP:        client.IP,
+	}
+
+	refreshID, err := newTokenID()
+	if err != nil {
+		return nil, err
+	}
+	session.RefreshHash = sha256Hex(refreshID)
+
+	if err := s.DB.WithContext(ctx).Create(session).Error; err != nil {
+		return nil, err
+	}
+
+	// Form credentials on every request aren't logins, only sessions are audited
+	if s.Guard != nil {
+		s.Guard.Audit(ctx, model.AuthEvent{
+			Event:  model.AuthEventLoginSucceeded,
+			Detail: fmt.Sprintf("session %d", session.ID),
+		}, user.Username, client)
+	}
+
+	return s.issue(user, session, refreshID)
+}
+
+// Refresh exchanges a refresh token for a new pair. Presenting a refresh
+// token that was already rotated revokes the session, it was stolen.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	claims, err := s.parse(refreshToken, tokenTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	var user model.User
+	var session model.AuthSession
+
+	refreshID, err := newTokenID()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&session, claims.SessionID).Error; err != nil {
+			return ErrInvalidToken
+		}
+		if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+			return ErrSessionRevoked
+		}
+
+		presented := sha256Hex(claims.ID)
+		if session.RefreshHash != presented {
+			return errReuseDetected
+		}
+
+		if err := tx.First(&user, session.UserID).Error; err != nil {
+			return ErrInvalidToken
+		}
+		if !user.IsActive {
+			return ErrUserInactive
+		}
+
+		// Only one of two concurrent refreshes with the same token can
+		// swap the hash, the other one is a reuse
+		res := tx.Model(&model.AuthSession{}).
+			Where("id = ? AND refresh_hash = ? AND revoked_at IS NULL", session.ID, presented).
+			Update("refresh_hash", sha256Hex(refreshID))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errReuseDetected
+		}
+		session.RefreshHash = sha256Hex(refreshID)
+		return nil
+	})
+	if errors.Is(err, errReuseDetected) {
+		// Revoke outside the rolled back transaction
+		if err := s.Revoke(ctx, session.ID); err != nil {
+			return nil, err
+		}
+		return nil, ErrSessionRevoked
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issue(&user, &session, refreshID)
+}
+
+// Authenticate verifies an access token and returns its user and claims.
+func (s *AuthService) Authenticate(ctx context.Context, accessToken string) (*model.User, *TokenClaims, error) {
+	claims, err := s.parse(accessToken, tokenTypeAccess)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db := s.DB.WithContext(ctx)
+
+	var session model.AuthSession
+	if err := db.First(&session, claims.SessionID).Error; err != nil {
+		return nil, nil, ErrInvalidToken
+	}
+	if session.RevokedAt != nil {
+		return nil, nil, ErrSessionRevoked
+	}
+
+	var user model.User
+	if err := db.First(&user, session.UserID).Error; err != nil {
+		return nil, nil, ErrInvalidToken
+	}
+	if !user.IsActive {
+		return nil, nil, ErrUserInactive
+	}
+
+	return &user, claims, nil
+}
+
+// Revoke ends one session.
+func (s *AuthService) Revoke(ctx context.Context, sessionID uint) error {
+	return s.DB.WithContext(ctx).
+		Model(&model.AuthSession{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeUser ends every session of a user.
+func (s *AuthService) RevokeUser(ctx context.Context, userID uint) error {
+	return s.DB.WithContext(ctx).
+		Model(&model.AuthSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (s *AuthService) issue(user *model.User, session *model.AuthSession, refreshID string) (*TokenPair, error) {
+	now := time.Now()
+
+	accessID, err := newTokenID()
+	if err != nil {
+		return nil, err
+	}
+
+	access, err := s.sign(TokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        accessID,
+			Issuer:    s.Issuer,
+			Subject:   strconv.FormatUint(uint64(user.ID), 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.AccessTTL)),
+		},
+		Type:      tokenTypeAccess,
+		SessionID: session.ID,
+		Username:  user.Username,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, err := s.sign(TokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        refreshID,
+			Issuer:    s.Issuer,
+			Subject:   strconv.FormatUint(uint64(user.ID), 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(session.ExpiresAt),
+		},
+		Type:      tokenTypeRefresh,
+		SessionID: session.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      access,
+		RefreshToken:     refresh,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(s.AccessTTL.Seconds()),
+		RefreshExpiresIn: int64(time.Until(session.ExpiresAt).Seconds()),
+	}, nil
+}
+
+func (s *AuthService) sign(claims TokenClaims) (string, error) {
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.Secret)
+}
+
+func (s *AuthService) parse(token, wantType string) (*TokenClaims, error) {
+	var claims TokenClaims
+	_, err := jwt.ParseWithClaims(
+		token,
+		&claims,
+		func(t *jwt.Token) (interface{}, error) { return s.Secret, nil },
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(s.Issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || claims.Type != wantType {
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
+
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate token id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// Budget splits the overall deadline of a recognition request across
+// its stages. A stage never outlives the overall deadline, and a zero
+// stage duration only inherits it.
+type Budget struct {
+	Total  time.Duration
+	Engine time.Duration
+	Member time.Duration
+	DB     time.Duration
+}
+
+// Request derives the per-request context bounded by Total.
+func (b Budget) Request(ctx context.Context) (context.Context, context.CancelFunc) {
+	if b.Total <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, b.Total)
+}
+
+// Stage derives a context for one stage of the pipeline.
+func (b Budget) Stage(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBudgetRequest(t *testing.T) {
+	ctx, cancel := Budget{Total: time.Second}.Request(context.Background())
+	defer cancel()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		t.Fatal("request has no deadline")
+	}
+	if left := time.Until(deadline); left <= 0 || left > time.Second {
+		t.Errorf("request deadline in %s, want within Total", left)
+	}
+
+	ctx, cancel = Budget{}.Request(context.Background())
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("request without Total has a deadline")
+	}
+	cancel()
+	if ctx.Err() == nil {
+		t.Error("request context not cancelled by its cancel func")
+	}
+}
+
+func TestBudgetStage(t *testing.T) {
+	b := Budget{Total: 50 * time.Millisecond, Member: time.Hour, Engine: 10 * time.Millisecond}
+
+	req, cancel := b.Request(context.Background())
+	defer cancel()
+	reqDeadline, _ := req.Deadline()
+
+	// A stage never outlives the request
+	member, memberCancel := b.Stage(req, b.Member)
+	defer memberCancel()
+	if d, _ := member.Deadline(); !d.Equal(reqDeadline) {
+		t.Errorf("member deadline %s, want the request deadline %s", d, reqDeadline)
+	}
+
+	// A zero stage only inherits it
+	db, dbCancel := b.Stage(req, b.DB)
+	defer dbCancel()
+	if d, _ := db.Deadline(); !d.Equal(reqDeadline) {
+		t.Errorf("db deadline %s, want the request deadline %s", d, reqDeadline)
+	}
+
+	// A shorter stage expires first, the request goes on
+	engine, engineCancel := b.Stage(req, b.Engine)
+	defer engineCancel()
+	if d, _ := engine.Deadline(); !d.Before(reqDeadline) {
+		t.Errorf("engine deadline %s, want before %s", d, reqDeadline)
+	}
+	<-engine.Done()
+	if req.Err() != nil {
+		t.Errorf("request ended with its engine stage: %v", req.Err())
+	}
+	<-member.Done()
+	if member.Err() != context.DeadlineExceeded {
+		t.Errorf("member err = %v, want deadline exceeded", member.Err())
+	}
+}
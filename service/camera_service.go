@@ -0,0 +1,342 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"plate-recognizer-api/model"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownCamera   = errors.New("camera is not registered at this location")
+	ErrCameraDisabled  = errors.New("camera is disabled")
+	ErrLocationInUse   = errors.New("location still has cameras")
+	ErrUnknownLocation = errors.New("location is not registered")
+)
+
+// LocationInput holds the editable fields of a location. A nil Active
+// keeps the current value (active for new locations).
+type LocationInput struct {
+	Name   string
+	Engine model.RecognitionSettings
+	Active *bool
+}
+
+// CameraInput holds the editable fields of a camera. A nil Active keeps
+// the current value (active for new cameras).
+type CameraInput struct {
+	Name       string
+	Direction  string
+	Lane       string
+	Engine     model.RecognitionSettings
+	DefaultMMC string
+	Active     *bool
+}
+
+// CameraService manages the location and camera registry.
+type CameraService struct {
+	DB *gorm.DB
+}
+
+func NewCameraService(db *gorm.DB) *CameraService {
+	return &CameraService{DB: db}
+}
+
+// GateCamera is a resolved camera with its location and the effective
+// recognition settings.
+type GateCamera struct {
+	Camera   model.Camera
+	Location model.Location
+	Settings model.RecognitionSettings
+}
+
+// Country is the plate format of the camera, its first pinned region.
+// It is empty when no region is pinned.
+func (g *GateCamera) Country() string {
+	if regions := g.Settings.RegionList(); len(regions) > 0 {
+		return regions[0]
+	}
+	return ""
+}
+
+// Resolve returns the camera a recognition comes from, rejecting unknown
+// or disabled cameras and cameras of a disabled location.
+func (s *CameraService) Resolve(ctx context.Context, locationCode, cameraID string) (*GateCamera, error) {
+	db := s.DB.WithContext(ctx)
+
+	var location model.Location
+	err := db.Where("code = ?", locationCode).First(&location).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUnknownLocation
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var camera model.Camera
+	err = db.Where("location_code = ? AND camera_id = ?", locationCode, cameraID).First(&camera).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUnknownCamera
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !location.Active || !camera.Active {
+		return nil, ErrCameraDisabled
+	}
+
+	return &GateCamera{
+		Camera:   camera,
+		Location: location,
+		Settings: camera.Engine.Merge(location.Engine),
+	}, nil
+}
+
+// --- Locations ---
+
+func (s *CameraService) ListLocations(ctx context.Context) ([]model.Location, error) {
+	var locations []model.Location
+	err := s.DB.WithContext(ctx).Order("code").Find(&locations).Error
+	return locations, err
+}
+
+// GetLocation returns gorm.ErrRecordNotFound when code doesn't exist.
+func (s *CameraService) GetLocation(ctx context.Context, code string) (*model.Location, error) {
+	var location model.Location
+	if err := s.DB.WithContext(ctx).Where("code = ?", code).First(&location).Error; err != nil {
+		return nil, err
+	}
+	return &location, nil
+}
+
+func (s *CameraService) CreateLocation(ctx context.Context, code string, in LocationInput) (*model.Location, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return nil, errors.New("code is required")
+	}
+	if err := validateSettings(&in.Engine); err != nil {
+		return nil, err
+	}
+
+	location := &model.Location{
+		Code:   code,
+		Name:   in.Name,
+		Engine: in.Engine,
+		Active: true,
+	}
+
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(location).Error; err != nil {
+			return err
+		}
+		return setActive(tx, location, &location.Active, in.Active)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return location, nil
+}
+
+func (s *CameraService) UpdateLocation(ctx context.Context, code string, in LocationInput) (*model.Location, error) {
+	if err := validateSettings(&in.Engine); err != nil {
+		return nil, err
+	}
+
+	location, err := s.GetLocation(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	location.Name = in.Name
+	location.Engine = in.Engine
+	if in.Active != nil {
+		location.Active = *in.Active
+	}
+
+	if err := s.DB.WithContext(ctx).Save(location).Error; err != nil {
+		return nil, err
+	}
+	return location, nil
+}
+
+// DeleteLocation refuses to remove a location that still has cameras.
+func (s *CameraService) DeleteLocation(ctx context.Context, code string) error {
+	db := s.DB.WithContext(ctx)
+
+	var cameras int64
+	if err := db.Model(&model.Camera{}).Where("location_code = ?", code).Count(&cameras).Error; err != nil {
+		return err
+	}
+	if cameras > 0 {
+		return ErrLocationInUse
+	}
+
+	res := db.Where("code = ?", code).Delete(&model.Location{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// --- Cameras ---
+
+// ListCameras returns the cameras of a location, or all of them when
+// locationCode is empty.
+func (s *CameraService) ListCameras(ctx context.Context, locationCode string) ([]model.Camera, error) {
+	tx := s.DB.WithContext(ctx)
+	if locationCode != "" {
+		tx = tx.Where("location_code = ?", locationCode)
+	}
+
+	var cameras []model.Camera
+	err := tx.Order("location_code").Order("camera_id").Find(&cameras).Error
+	return cameras, err
+}
+
+// GetCamera returns gorm.ErrRecordNotFound when the camera doesn't exist.
+func (s *CameraService) GetCamera(ctx context.Context, locationCode, cameraID string) (*model.Camera, error) {
+	var camera model.Camera
+	err := s.DB.WithContext(ctx).
+		Where("location_code = ? AND camera_id = ?", locationCode, cameraID).
+		First(&camera).Error
+	if err != nil {
+		return nil, err
+	}
+	return &camera, nil
+}
+
+func (s *CameraService) CreateCamera(ctx context.Context, locationCode, cameraID string, in CameraInput) (*model.Camera, error) {
+	cameraID = strings.TrimSpace(cameraID)
+	if cameraID == "" {
+		return nil, errors.New("camera_id is required")
+	}
+	if err := validateCameraInput(&in); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.GetLocation(ctx, locationCode); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUnknownLocation
+		}
+		return nil, err
+	}
+
+	camera := &model.Camera{
+		LocationCode: locationCode,
+		CameraID:     cameraID,
+		Active:       true,
+	}
+	applyCameraInput(camera, in)
+
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(camera).Error; err != nil {
+			return err
+		}
+		return setActive(tx, camera, &camera.Active, in.Active)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return camera, nil
+}
+
+func (s *CameraService) UpdateCamera(ctx context.Context, locationCode, cameraID string, in CameraInput) (*model.Camera, error) {
+	if err := validateCameraInput(&in); err != nil {
+		return nil, err
+	}
+
+	camera, err := s.GetCamera(ctx, locationCode, cameraID)
+	if err != nil {
+		return nil, err
+	}
+
+	applyCameraInput(camera, in)
+	if in.Active != nil {
+		camera.Active = *in.Active
+	}
+
+	if err := s.DB.WithContext(ctx).Save(camera).Error; err != nil {
+		return nil, err
+	}
+	return camera, nil
+}
+
+func (s *CameraService) DeleteCamera(ctx context.Context, locationCode, cameraID string) error {
+	res := s.DB.WithContext(ctx).
+		Where("location_code = ? AND camera_id = ?", locationCode, cameraID).
+		Delete(&model.Camera{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func validateCameraInput(in *CameraInput) error {
+	in.Direction = strings.ToLower(strings.TrimSpace(in.Direction))
+	if in.Direction != DirectionEntry && in.Direction != DirectionExit {
+		return errors.New("direction must be entry or exit")
+	}
+	return validateSettings(&in.Engine)
+}
+
+// validateSettings normalizes settings and rejects values the engine
+// would refuse.
+func validateSettings(s *model.RecognitionSettings) error {
+	regions := s.RegionList()
+	for i := range regions {
+		regions[i] = strings.ToLower(regions[i])
+	}
+	s.Regions = strings.Join(regions, ",")
+
+	if s.Threshold != nil && (*s.Threshold < 0 || *s.Threshold > 1) {
+		return errors.New("threshold must be between 0 and 1")
+	}
+	if s.MinConfidence != nil && (*s.MinConfidence < 0 || *s.MinConfidence > 1) {
+		return errors.New("min_confidence must be between 0 and 1")
+	}
+
+	s.Mode = strings.ToLower(s.Mode)
+	s.Region = strings.ToLower(s.Region)
+	s.DetectionRule = strings.ToLower(s.DetectionRule)
+	s.DetectionMode = strings.ToLower(s.DetectionMode)
+
+	switch {
+	case s.Mode != "" && s.Mode != "fast" && s.Mode != "redaction":
+		return errors.New("mode must be fast or redaction")
+	case s.Region != "" && s.Region != "strict":
+		return errors.New("region must be strict")
+	case s.DetectionRule != "" && s.DetectionRule != "strict":
+		return errors.New("detection_rule must be strict")
+	case s.DetectionMode != "" && s.DetectionMode != "plate" && s.DetectionMode != "vehicle":
+		return errors.New("detection_mode must be plate or vehicle")
+	}
+	return nil
+}
+
+func applyCameraInput(camera *model.Camera, in CameraInput) {
+	camera.Name = in.Name
+	camera.Direction = in.Direction
+	camera.Lane = in.Lane
+	camera.Engine = in.Engine
+	camera.DefaultMMC = in.DefaultMMC
+}
+
+// setActive stores an explicit inactive flag after Create, GORM skips
+// false in favour of the column default.
+func setActive(tx *gorm.DB, row interface{}, field *bool, active *bool) error {
+	if active == nil || *active {
+		return nil
+	}
+	*field = false
+	return tx.Model(row).Update("active", false).Error
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"plate-recognizer-api/config"
+	"plate-recognizer-api/internal/readerpool"
+	"plate-recognizer-api/model"
+)
+
+// Candidate is a single plate read returned by a recognition engine.
+type Candidate struct {
+	Plate      string           `json:"plate"`
+	Score      float64          `json:"score"`
+	DScore     float64          `json:"dscore"`
+	Box        *Box             `json:"box,omitempty"`
+	Region     *Region          `json:"region,omitempty"`
+	Alternates []PlateCandidate `json:"alternates,omitempty"`
+	Vehicle    *VehicleInfo     `json:"vehicle,omitempty"`
+}
+
+// VehicleInfo is the engine-neutral view of the vehicle carrying a plate.
+// Attributes the engine did not report are left empty.
+type VehicleInfo struct {
+	Type        string  `json:"type,omitempty"`
+	Score       float64 `json:"score"`
+	Box         *Box    `json:"box,omitempty"`
+	Color       string  `json:"color,omitempty"`
+	Make        string  `json:"make,omitempty"`
+	Model       string  `json:"model,omitempty"`
+	Orientation string  `json:"orientation,omitempty"`
+}
+
+// RecognizeOptions carries the per-request hints forwarded to the engine.
+type RecognizeOptions struct {
+	MMC           string
+	CameraID      string
+	TransactionNo string
+	Timestamp     time.Time
+
+	// Regions narrows plate patterns, e.g. ["id"]
+	Regions []string
+	Config  *EngineConfig
+}
+
+// EngineConfig is the SDK "config" field. Engines without an equivalent
+// ignore it.
+type EngineConfig struct {
+	Threshold     *float64 `json:"threshold,omitempty"`
+	Mode          string   `json:"mode,omitempty"`
+	Region        string   `json:"region,omitempty"`
+	DetectionRule string   `json:"detection_rule,omitempty"`
+	DetectionMode string   `json:"detection_mode,omitempty"`
+}
+
+// NewEngineConfig returns nil when settings leave everything to the
+// engine defaults.
+func NewEngineConfig(settings model.RecognitionSettings) *EngineConfig {
+	cfg := EngineConfig{
+		Threshold:     settings.Threshold,
+		Mode:          settings.Mode,
+		Region:        settings.Region,
+		DetectionRule: settings.DetectionRule,
+		DetectionMode: settings.DetectionMode,
+	}
+	if cfg == (EngineConfig{}) {
+		return nil
+	}
+	return &cfg
+}
+
+// Recognition is the outcome of one engine call. Besides the parsed
+// candidates it keeps the raw payload and transport details so the
+// exchange can be replayed later.
+type Recognition struct {
+	Candidates []Candidate
+	Raw        []byte
+	EngineURL  string
+	Latency    time.Duration
+	HTTPStatus int
+}
+
+// RecognitionEngine reads licence plates from an image.
+// Implementations must return candidates ordered by relevance,
+// the first one being the engine's best guess.
+type RecognitionEngine interface {
+	Recognize(ctx context.Context, image []byte, opts RecognizeOptions) (*Recognition, error)
+}
+
+// NewEngine builds the engine selected by RECOGNITION_ENGINE.
+// Supported values: platerecognizer (default), openalpr and fake.
+// The pool is only used by the Plate Recognizer engine.
+func NewEngine(env *config.Env, pool *readerpool.Pool) (RecognitionEngine, error) {
+	switch strings.ToLower(env.RecognitionEngine) {
+	case "", "platerecognizer":
+		if pool == nil {
+			return nil, fmt.Errorf("plate-reader pool is required for the platerecognizer engine")
+		}
+		return NewPlateRecognizerEngine(env.PlateRecognizerToken, pool), nil
+	case "openalpr":
+		if env.OpenALPRURL == "" {
+			return nil, fmt.Errorf("OPENALPR_URL is required for the openalpr engine")
+		}
+		return NewOpenALPREngine(env.OpenALPRURL, env.OpenALPRSecretKey, env.OpenALPRCountry), nil
+	case "fake":
+		plate := env.FakeEnginePlate
+		if plate == "" {
+			plate = "B1234XYZ"
+		}
+		return &FakeEngine{
+			Candidates: []Candidate{{Plate: plate, Score: 1}},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown recognition engine %q", env.RecognitionEngine)
+	}
+}
+
+// Recognize reads the image at imagePath and runs it through the engine.
+// It fails when the engine does not detect any plate.
+func Recognize(
+	ctx context.Context,
+	engine RecognitionEngine,
+	imagePath string,
+	opts RecognizeOptions,
+) (*Recognition, error) {
+	image, err := os.ReadFile(imagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Timestamp.IsZero() {
+		opts.Timestamp = time.Now()
+	}
+
+	rec, err := engine.Recognize(ctx, image, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rec.Candidates) == 0 {
+		return nil, fmt.Errorf("no plate detected")
+	}
+
+	return rec, nil
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// FakeEngine is an in-process RecognitionEngine that returns canned
+// candidates. It records every call so tests can assert on the options
+// forwarded by the service layer.
+type FakeEngine struct {
+	Candidates []Candidate
+	Err        error
+
+	mu    sync.Mutex
+	Calls []RecognizeOptions
+}
+
+func (e *FakeEngine) Recognize(
+	ctx context.Context,
+	image []byte,
+	opts RecognizeOptions,
+) (*Recognition, error) {
+	e.mu.Lock()
+	e.Calls = append(e.Calls, opts)
+	e.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if e.Err != nil {
+		return nil, e.Err
+	}
+
+	raw, err := json.Marshal(map[string]interface{}{"results": e.Candidates})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Recognition{
+		Candidates: e.Candidates,
+		Raw:        raw,
+		EngineURL:  "fake://",
+		HTTPStatus: http.StatusOK,
+	}, nil
+}
@@ -0,0 +1,350 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"plate-recognizer-api/internal/minio"
+	"plate-recognizer-api/model"
+
+	"gorm.io/gorm"
+)
+
+// ImageStore persists evidence images, e.g. the MinIO client.
+type ImageStore interface {
+	UploadFile(ctx context.Context, bucket, objectName, filePath string, opts minio.UploadOptions) error
+}
+
+// UploadJob is the spooled description of one pending upload.
+type UploadJob struct {
+	ID            string            `json:"id"`
+	PlateLogID    uint              `json:"plate_log_id"`
+	Bucket        string            `json:"bucket"`
+	ObjectName    string            `json:"object_name"`
+	ContentType   string            `json:"content_type"`
+	Metadata      map[string]string `json:"metadata"`
+	Attempts      int               `json:"attempts"`
+	LastError     string            `json:"last_error,omitempty"`
+	NextAttemptAt time.Time         `json:"next_attempt_at"`
+}
+
+// ImageUploader uploads images in the background. Every image is first
+// copied to SpoolDir next to a JSON job file, so pending uploads survive
+// restarts and are retried with exponential backoff until they succeed.
+type ImageUploader struct {
+	DB       *gorm.DB
+	Store    ImageStore
+	Bucket   string
+	Keys     *ObjectKeyTemplate
+	SpoolDir string
+
+	Workers        int
+	AttemptTimeout time.Duration
+	BaseBackoff    time.Duration
+	MaxBackoff     time.Duration
+	ScanInterval   time.Duration
+
+	jobs     chan UploadJob
+	mu       sync.Mutex
+	inFlight map[string]bool
+}
+
+func NewImageUploader(
+	db *gorm.DB,
+	store ImageStore,
+	bucket string,
+	keys *ObjectKeyTemplate,
+	spoolDir string,
+	workers int,
+	attemptTimeout, maxBackoff time.Duration,
+) (*ImageUploader, error) {
+	if err := os.MkdirAll(spoolDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create spool dir: %w", err)
+	}
+
+	if workers <= 0 {
+		workers = 4
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = 10 * time.Minute
+	}
+
+	return &ImageUploader{
+		DB:       db,
+		Store:    store,
+		Bucket:   bucket,
+		Keys:     keys,
+		SpoolDir: spoolDir,
+
+		Workers:        workers,
+		AttemptTimeout: attemptTimeout,
+		BaseBackoff:    5 * time.Second,
+		MaxBackoff:     maxBackoff,
+		ScanInterval:   30 * time.Second,
+
+		jobs:     make(chan UploadJob, workers*16),
+		inFlight: make(map[string]bool),
+	}, nil
+}
+
+// Enqueue spools the image for the given plate log. The image is copied,
+// so the caller may remove imagePath as soon as Enqueue returns.
+func (u *ImageUploader) Enqueue(plateLog *model.PlateLog, imagePath string) error {
+	contentType, err := detectContentType(imagePath)
+	if err != nil {
+		return fmt.Errorf("read image: %w", err)
+	}
+
+	job := UploadJob{
+		ID:          fmt.Sprintf("%d-%d", plateLog.ID, time.Now().UnixNano()),
+		PlateLogID:  plateLog.ID,
+		Bucket:      u.Bucket,
+		ObjectName:  u.Keys.Render(plateLog, contentType),
+		ContentType: contentType,
+		Metadata: map[string]string{
+			"plate":          plateLog.Plate,
+			"score":          plateLog.Accuracy,
+			"transaction-no": plateLog.TransactionNo,
+			"location-code":  plateLog.LocationCode,
+			"camera-id":      plateLog.CameraID,
+			"plate-log-id":   fmt.Sprint(plateLog.ID),
+		},
+		NextAttemptAt: time.Now(),
+	}
+
+	if err := copyFile(imagePath, u.imagePath(job.ID)); err != nil {
+		return fmt.Errorf("spool image: %w", err)
+	}
+	if err := u.writeJob(job); err != nil {
+		os.Remove(u.imagePath(job.ID))
+		return fmt.Errorf("spool job: %w", err)
+	}
+
+	u.dispatch(job)
+	return nil
+}
+
+// Start runs the workers and the spool scanner until ctx is cancelled.
+// Jobs left in the spool by a previous run are picked up immediately.
+func (u *ImageUploader) Start(ctx context.Context) {
+	for i := 0; i < u.Workers; i++ {
+		go u.worker(ctx)
+	}
+
+	go func() {
+		ticker := time.NewTicker(u.ScanInterval)
+		defer ticker.Stop()
+
+		u.scan()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				u.scan()
+			}
+		}
+	}()
+}
+
+// dispatch hands a job to the workers unless it is already being handled.
+// When the queue is full the job stays spooled for the next scan.
+func (u *ImageUploader) dispatch(job UploadJob) {
+	u.mu.Lock()
+	if u.inFlight[job.ID] {
+		u.mu.Unlock()
+		return
+	}
+	u.inFlight[job.ID] = true
+	u.mu.Unlock()
+
+	select {
+	case u.jobs <- job:
+	default:
+		u.done(job.ID)
+	}
+}
+
+func (u *ImageUploader) done(id string) {
+	u.mu.Lock()
+	delete(u.inFlight, id)
+	u.mu.Unlock()
+}
+
+func (u *ImageUploader) scan() {
+	matches, err := filepath.Glob(filepath.Join(u.SpoolDir, "*.json"))
+	if err != nil {
+		log.Printf("image spool scan failed: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, path := range matches {
+		job, err := readJob(path)
+		if err != nil {
+			log.Printf("image spool: skipping %s: %v", path, err)
+			continue
+		}
+		if job.NextAttemptAt.After(now) {
+			continue
+		}
+		u.dispatch(job)
+	}
+}
+
+func (u *ImageUploader) worker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-u.jobs:
+			u.process(ctx, job)
+			u.done(job.ID)
+		}
+	}
+}
+
+func (u *ImageUploader) process(ctx context.Context, job UploadJob) {
+	attemptCtx, cancel := context.WithCancel(ctx)
+	if u.AttemptTimeout > 0 {
+		attemptCtx, cancel = context.WithTimeout(ctx, u.AttemptTimeout)
+	}
+	defer cancel()
+
+	err := u.Store.UploadFile(
+		attemptCtx,
+		job.Bucket,
+		job.ObjectName,
+		u.imagePath(job.ID),
+		minio.UploadOptions{
+			ContentType: job.ContentType,
+			Metadata:    job.Metadata,
+		},
+	)
+	if err != nil {
+		job.Attempts++
+		job.LastError = err.Error()
+		job.NextAttemptAt = time.Now().Add(u.backoff(job.Attempts))
+		log.Printf(
+			"MinIO upload failed for log %d (attempt %d, retry at %s): %v",
+			job.PlateLogID,
+			job.Attempts,
+			job.NextAttemptAt.Format(time.RFC3339),
+			err,
+		)
+		if err := u.writeJob(job); err != nil {
+			log.Printf("image spool: failed to update job %s: %v", job.ID, err)
+		}
+		return
+	}
+
+	if err := u.DB.WithContext(ctx).
+		Model(&model.PlateLog{}).
+		Where("id = ?", job.PlateLogID).
+		Updates(map[string]interface{}{
+			"image_bucket":  job.Bucket,
+			"image_key":     job.ObjectName,
+			"image_pending": false,
+		}).Error; err != nil {
+		// Keep the job, the upload is idempotent
+		log.Printf("failed to back-fill image for log %d: %v", job.PlateLogID, err)
+		return
+	}
+
+	os.Remove(u.imagePath(job.ID))
+	os.Remove(u.jobPath(job.ID))
+}
+
+func (u *ImageUploader) backoff(attempts int) time.Duration {
+	d := u.BaseBackoff << min(attempts-1, 16)
+	if d > u.MaxBackoff {
+		d = u.MaxBackoff
+	}
+	return d
+}
+
+func (u *ImageUploader) imagePath(id string) string {
+	return filepath.Join(u.SpoolDir, id+".img")
+}
+
+func (u *ImageUploader) jobPath(id string) string {
+	return filepath.Join(u.SpoolDir, id+".json")
+}
+
+// writeJob replaces the job file atomically.
+func (u *ImageUploader) writeJob(job UploadJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	tmp := u.jobPath(job.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, u.jobPath(job.ID))
+}
+
+func readJob(path string) (UploadJob, error) {
+	var job UploadJob
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return job, err
+	}
+	if err := json.Unmarshal(data, &job); err != nil {
+		return job, err
+	}
+	if job.ID == "" {
+		job.ID = strings.TrimSuffix(filepath.Base(path), ".json")
+	}
+	return job, nil
+}
+
+func detectContentType(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+
+	return http.DetectContentType(head[:n]), nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"plate-recognizer-api/model"
+)
+
+// ImageSigner issues temporary read URLs for private objects.
+type ImageSigner interface {
+	PresignedGetURL(ctx context.Context, bucket, objectName string, expiry time.Duration) (string, error)
+}
+
+// ImageURLResolver turns the stored image location of a plate log into a
+// URL the client can open.
+type ImageURLResolver struct {
+	Signer ImageSigner
+	Expiry time.Duration
+}
+
+// Resolve returns a presigned URL for private images, the stored URL for
+// legacy rows, or "" when the log has no image (yet).
+func (r *ImageURLResolver) Resolve(ctx context.Context, plateLog *model.PlateLog) (string, error) {
+	if plateLog.ImageKey == "" || r == nil || r.Signer == nil {
+		return plateLog.ImageURL, nil
+	}
+
+	return r.Signer.PresignedGetURL(ctx, plateLog.ImageBucket, plateLog.ImageKey, r.Expiry)
+}
@@ -0,0 +1,381 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"plate-recognizer-api/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	DefaultAuthEventLimit = 100
+	MaxAuthEventLimit     = 1000
+)
+
+var (
+	ErrTooManyAttempts = errors.New("too many failed logins, retry later")
+	ErrLoginLocked     = errors.New("login temporarily locked after repeated failures")
+)
+
+// LoginBlockedError refuses a login before the password is checked.
+// It wraps ErrTooManyAttempts or ErrLoginLocked.
+type LoginBlockedError struct {
+	Err   error
+	Until time.Time
+}
+
+func (e *LoginBlockedError) Error() string { return e.Err.Error() }
+func (e *LoginBlockedError) Unwrap() error { return e.Err }
+
+// RetryAfter is how long the client has to wait, at least a second.
+func (e *LoginBlockedError) RetryAfter() time.Duration {
+	d := time.Until(e.Until).Round(time.Second)
+	if d < time.Second {
+		d = time.Second
+	}
+	return d
+}
+
+// LoginGuard throttles password logins. Each failure delays the next
+// attempt of the same username, BaseDelay doubling up to MaxDelay, and
+// MaxFailures of a username (MaxIPFailures of an IP) within Window lock
+// it out for Lockout. IPs only lock, gates behind one address shouldn't
+// wait on each other's typos. Shared device accounts are throttled by IP
+// only, anyone on the LAN could otherwise lock every gate out. State
+// lives in the DB so every instance sees it.
+type LoginGuard struct {
+	DB *gorm.DB
+
+	MaxFailures   int
+	MaxIPFailures int
+	Lockout       time.Duration
+	Window        time.Duration
+	BaseDelay     time.Duration
+	MaxDelay      time.Duration
+}
+
+func NewLoginGuard(db *gorm.DB) *LoginGuard {
+	return &LoginGuard{
+		DB:            db,
+		MaxFailures:   5,
+		MaxIPFailures: 20,
+		Lockout:       15 * time.Minute,
+		Window:        15 * time.Minute,
+		BaseDelay:     time.Second,
+		MaxDelay:      30 * time.Second,
+	}
+}
+
+// Attempt runs verify, the password check of a login, while the
+// throttles of its username and IP are locked. Parallel logins of one
+// username can't all pass the check before the first failure is
+// recorded. It returns a *LoginBlockedError without calling verify when
+// the login is throttled, and the error of verify otherwise; an
+// ErrInvalidCredentials counts as a failure and nil clears the username.
+// With shared set only the IP is throttled.
+func (g *LoginGuard) Attempt(
+	ctx context.Context,
+	username string,
+	shared bool,
+	client ClientInfo,
+	verify func() error,
+) error {
+	var result error
+	err := g.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Always username first, two logins never wait on each other's lock
+		if !shared {
+			if err := lockThrottle(tx, model.ThrottleUsername, username); err != nil {
+				return err
+			}
+		}
+		if client.IP != "" {
+			if err := lockThrottle(tx, model.ThrottleIP, client.IP); err != nil {
+				return err
+			}
+		}
+
+		if err := g.check(ctx, tx, username, shared, client); err != nil {
+			return err
+		}
+
+		result = verify()
+		switch {
+		case errors.Is(result, ErrInvalidCredentials):
+			return g.fail(ctx, tx, username, shared, client)
+		case result == nil:
+			return g.succeed(tx, username)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return result
+}
+
+// lockThrottle serializes the logins of one username or IP until the
+// transaction ends. There may be no throttle row to lock yet.
+func lockThrottle(tx *gorm.DB, kind, key string) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "login|"+kind+"|"+key).Error
+}
+
+// check refuses a login while its username or IP is locked, or while
+// the username waits out the delay of its last failure. With shared set
+// only the IP is checked.
+func (g *LoginGuard) check(ctx context.Context, tx *gorm.DB, username string, shared bool, client ClientInfo) error {
+	q := tx.Where("kind = ? AND key = ?", model.ThrottleIP, client.IP)
+	if !shared {
+		q = q.Or("kind = ? AND key = ?", model.ThrottleUsername, username)
+	}
+
+	var throttles []model.LoginThrottle
+	err := q.Find(&throttles).Error
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var blocked *LoginBlockedError
+	for i := range throttles {
+		t := &throttles[i]
+
+		var until time.Time
+		reason := ErrTooManyAttempts
+		switch {
+		case t.Locked(now):
+			until, reason = *t.LockedUntil, ErrLoginLocked
+		case t.Kind == model.ThrottleUsername && g.recent(t, now):
+			until = t.LastFailureAt.Add(g.delay(t.Failures))
+		}
+
+		if until.After(now) && (blocked == nil || until.After(blocked.Until)) {
+			blocked = &LoginBlockedError{Err: reason, Until: until}
+		}
+	}
+	if blocked == nil {
+		return nil
+	}
+
+	g.Audit(ctx, model.AuthEvent{
+		Event:  model.AuthEventLoginBlocked,
+		Detail: fmt.Sprintf("%v until %s", blocked.Err, blocked.Until.UTC().Format(time.RFC3339)),
+	}, username, client)
+	return blocked
+}
+
+// fail records a failed login against the username and the IP, only
+// against the IP with shared set.
+func (g *LoginGuard) fail(ctx context.Context, tx *gorm.DB, username string, shared bool, client ClientInfo) error {
+	g.Audit(ctx, model.AuthEvent{Event: model.AuthEventLoginFailed}, username, client)
+
+	if !shared {
+		if err := g.count(ctx, tx, model.ThrottleUsername, username, g.MaxFailures, username, client); err != nil {
+			return err
+		}
+	}
+	if client.IP == "" {
+		return nil
+	}
+	return g.count(ctx, tx, model.ThrottleIP, client.IP, g.MaxIPFailures, username, client)
+}
+
+func (g *LoginGuard) count(ctx context.Context, db *gorm.DB, kind, key string, maxFailures int, username string, client ClientInfo) error {
+	now := time.Now()
+	var throttle model.LoginThrottle
+	locked := false
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		row := model.LoginThrottle{Kind: kind, Key: key}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&row).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("kind = ? AND key = ?", kind, key).
+			First(&throttle).Error; err != nil {
+			return err
+		}
+
+		if !g.recent(&throttle, now) {
+			throttle.Failures = 0
+		}
+		throttle.Failures++
+		throttle.LastFailureAt = &now
+
+		// The lockout is the penalty, the count starts over after it
+		if maxFailures > 0 && throttle.Failures >= maxFailures {
+			until := now.Add(g.Lockout)
+			throttle.LockedUntil = &until
+			throttle.Failures = 0
+			locked = true
+		}
+
+		return tx.Model(&throttle).
+			Select("failures", "last_failure_at", "locked_until").
+			Updates(&throttle).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if locked {
+		g.Audit(ctx, model.AuthEvent{
+			Event:  model.AuthEventLocked,
+			Detail: fmt.Sprintf("%s %s locked until %s", kind, key, throttle.LockedUntil.UTC().Format(time.RFC3339)),
+		}, username, client)
+	}
+	return nil
+}
+
+// succeed clears the failures of a username after a good password. The
+// IP keeps its count, one valid account must not cover for guessing
+// at others.
+func (g *LoginGuard) succeed(tx *gorm.DB, username string) error {
+	return tx.
+		Where("kind = ? AND key = ?", model.ThrottleUsername, username).
+		Delete(&model.LoginThrottle{}).Error
+}
+
+// Unlock clears the lockout and failures of a username or IP,
+// gorm.ErrRecordNotFound when there are none.
+func (g *LoginGuard) Unlock(ctx context.Context, kind, key, actor string) error {
+	var throttle model.LoginThrottle
+	err := g.DB.WithContext(ctx).
+		Where("kind = ? AND key = ?", kind, key).
+		First(&throttle).Error
+	if err != nil {
+		return err
+	}
+	return g.unlock(ctx, &throttle, actor)
+}
+
+// UnlockByID is Unlock for one listed throttle.
+func (g *LoginGuard) UnlockByID(ctx context.Context, id uint, actor string) error {
+	var throttle model.LoginThrottle
+	if err := g.DB.WithContext(ctx).First(&throttle, id).Error; err != nil {
+		return err
+	}
+	return g.unlock(ctx, &throttle, actor)
+}
+
+func (g *LoginGuard) unlock(ctx context.Context, throttle *model.LoginThrottle, actor string) error {
+	if err := g.DB.WithContext(ctx).Delete(throttle).Error; err != nil {
+		return err
+	}
+
+	event := model.AuthEvent{
+		Event:  model.AuthEventUnlocked,
+		Actor:  actor,
+		Detail: fmt.Sprintf("%s %s unlocked", throttle.Kind, throttle.Key),
+	}
+	client := ClientInfo{}
+	username := ""
+	if throttle.Kind == model.ThrottleIP {
+		client.IP = throttle.Key
+	} else {
+		username = throttle.Key
+	}
+	g.Audit(ctx, event, username, client)
+	return nil
+}
+
+// Throttles lists the usernames and IPs that are locked or have recent
+// failures, most recent first.
+func (g *LoginGuard) Throttles(ctx context.Context) ([]model.LoginThrottle, error) {
+	now := time.Now()
+	var throttles []model.LoginThrottle
+	err := g.DB.WithContext(ctx).
+		Where("locked_until > ? OR last_failure_at > ?", now, now.Add(-g.Window)).
+		Order("updated_at DESC").
+		Find(&throttles).Error
+	return throttles, err
+}
+
+// Prune drops the throttles with nothing left to enforce.
+func (g *LoginGuard) Prune(ctx context.Context) (int64, error) {
+	now := time.Now()
+	res := g.DB.WithContext(ctx).
+		Where("(locked_until IS NULL OR locked_until <= ?) AND (last_failure_at IS NULL OR last_failure_at <= ?)", now, now.Add(-g.Window)).
+		Delete(&model.LoginThrottle{})
+	return res.RowsAffected, res.Error
+}
+
+// Start prunes expired throttles every interval until ctx is cancelled.
+func (g *LoginGuard) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := g.Prune(ctx); err != nil {
+					log.Printf("login throttle prune failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// AuthEventFilter selects audit entries, empty fields match all.
+type AuthEventFilter struct {
+	Event    string
+	Username string
+	IP       string
+	Limit    int
+}
+
+// Events lists audit entries, newest first.
+func (g *LoginGuard) Events(ctx context.Context, f AuthEventFilter) ([]model.AuthEvent, error) {
+	limit := f.Limit
+	if limit <= 0 {
+		limit = DefaultAuthEventLimit
+	}
+	if limit > MaxAuthEventLimit {
+		limit = MaxAuthEventLimit
+	}
+
+	var events []model.AuthEvent
+	err := g.DB.WithContext(ctx).
+		Where(&model.AuthEvent{Event: f.Event, Username: f.Username, IP: f.IP}).
+		Order("id DESC").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+// Audit stores an audit entry. It only logs failures, a lost entry must
+// not fail the login.
+func (g *LoginGuard) Audit(ctx context.Context, event model.AuthEvent, username string, client ClientInfo) {
+	event.Username = truncate(username, 255)
+	event.IP = client.IP
+	event.UserAgent = truncate(client.UserAgent, 255)
+
+	if err := g.DB.WithContext(ctx).Create(&event).Error; err != nil {
+		log.Printf("failed to write auth event %s for %q: %v", event.Event, username, err)
+	}
+}
+
+// recent reports whether the failures of t still count.
+func (g *LoginGuard) recent(t *model.LoginThrottle, now time.Time) bool {
+	return t.Failures > 0 && t.LastFailureAt != nil && now.Sub(*t.LastFailureAt) < g.Window
+}
+
+// delay is the wait after the n-th consecutive failure.
+func (g *LoginGuard) delay(failures int) time.Duration {
+	d := g.BaseDelay
+	for i := 1; i < failures && d < g.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > g.MaxDelay {
+		d = g.MaxDelay
+	}
+	return d
+}
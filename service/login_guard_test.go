@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"plate-recognizer-api/model"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// testPostgres connects to TEST_DATABASE_DSN, tests needing row locks
+// are skipped without it.
+func testPostgres(t *testing.T, models ...interface{}) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestCheckCredentialsConcurrentFailures(t *testing.T) {
+	db := testPostgres(t, &model.User{}, &model.LoginThrottle{}, &model.AuthEvent{})
+
+	username := fmt.Sprintf("race-%d", time.Now().UnixNano())
+	client := ClientInfo{IP: "203.0.113.7"}
+	t.Cleanup(func() {
+		db.Where("(kind = ? AND key = ?) OR (kind = ? AND key = ?)",
+			model.ThrottleUsername, username, model.ThrottleIP, client.IP).
+			Delete(&model.LoginThrottle{})
+	})
+
+	guard := NewLoginGuard(db)
+	guard.BaseDelay = time.Minute
+	s := &AuthService{DB: db, Guard: guard}
+
+	const logins = 20
+	var (
+		wg               sync.WaitGroup
+		mu               sync.Mutex
+		invalid, blocked int
+	)
+	for range logins {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.CheckCredentials(context.Background(), username, "guess", client)
+
+			mu.Lock()
+			defer mu.Unlock()
+			var b *LoginBlockedError
+			switch {
+			case errors.Is(err, ErrInvalidCredentials):
+				invalid++
+			case errors.As(err, &b):
+				blocked++
+			default:
+				t.Errorf("CheckCredentials = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The first failure delays every other attempt of the burst
+	if invalid != 1 || blocked != logins-1 {
+		t.Errorf("%d passwords checked, %d blocked, want 1 and %d", invalid, blocked, logins-1)
+	}
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"plate-recognizer-api/internal/platenorm"
+	"plate-recognizer-api/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MemberCache stores member lookups by plate.
+type MemberCache interface {
+	Get(ctx context.Context, plate string) (*MemberInfo, bool)
+	Set(ctx context.Context, plate string, info *MemberInfo, ttl time.Duration)
+	Delete(ctx context.Context, plates ...string) error
+	Flush(ctx context.Context) error
+}
+
+// NewMemberCache builds the cache selected by MEMBER_CACHE.
+// It returns nil when caching is off. maxEntries bounds the memory cache.
+func NewMemberCache(kind string, db *gorm.DB, maxEntries int) (MemberCache, error) {
+	switch strings.ToLower(kind) {
+	case "", "off", "none":
+		return nil, nil
+	case "memory":
+		return NewMemoryMemberCache(maxEntries), nil
+	case "db":
+		return NewDBMemberCache(db), nil
+	default:
+		return nil, fmt.Errorf("unknown member cache %q", kind)
+	}
+}
+
+// CachedMemberClient serves lookups from a MemberCache and falls back to
+// the wrapped client on a miss. Non-members are cached with NegativeTTL.
+type CachedMemberClient struct {
+	Next        MemberClient
+	Cache       MemberCache
+	TTL         time.Duration
+	NegativeTTL time.Duration
+}
+
+func NewCachedMemberClient(next MemberClient, cache MemberCache, ttl, negativeTTL time.Duration) *CachedMemberClient {
+	return &CachedMemberClient{
+		Next:        next,
+		Cache:       cache,
+		TTL:         ttl,
+		NegativeTTL: negativeTTL,
+	}
+}
+
+func (m *CachedMemberClient) CheckPlate(ctx context.Context, plate string) (*MemberInfo, error) {
+	key := platenorm.Clean(plate)
+
+	if info, ok := m.Cache.Get(ctx, key); ok {
+		return info, nil
+	}
+
+	info, err := m.Next.CheckPlate(ctx, plate)
+	if err != nil {
+		// Failures are never cached, the next gate event retries
+		return nil, err
+	}
+
+	ttl := m.TTL
+	if info.Category == "" {
+		ttl = m.NegativeTTL
+	} else if info.ValidUntil != nil {
+		// Don't serve a membership past its expiry
+		if left := time.Until(*info.ValidUntil); left < ttl {
+			ttl = left
+		}
+	}
+
+	if ttl > 0 {
+		m.Cache.Set(ctx, key, info, ttl)
+	}
+
+	return info, nil
+}
+
+type memoryCacheEntry struct {
+	info      MemberInfo
+	expiresAt time.Time
+}
+
+// memorySweepEvery is how many Set calls pass between sweeps of the
+// expired entries.
+const memorySweepEvery = 1024
+
+// MemoryMemberCache is a per-process cache of at most MaxEntries plates.
+// When it is full of live entries, a tenth of them is evicted at random.
+type MemoryMemberCache struct {
+	MaxEntries int
+
+	mu      sync.RWMutex
+	entries map[string]memoryCacheEntry
+	sets    int
+}
+
+func NewMemoryMemberCache(maxEntries int) *MemoryMemberCache {
+	if maxEntries <= 0 {
+		maxEntries = 100000
+	}
+
+	return &MemoryMemberCache{
+		MaxEntries: maxEntries,
+		entries:    make(map[string]memoryCacheEntry),
+	}
+}
+
+func (c *MemoryMemberCache) Get(ctx context.Context, plate string) (*MemberInfo, bool) {
+	c.mu.RLock()
+	entry, ok := c.entries[plate]
+	c.mu.RUnlock()
+
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+
+	info := entry.info
+	return &info, true
+}
+
+func (c *MemoryMemberCache) Set(ctx context.Context, plate string, info *MemberInfo, ttl time.Duration) {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sets++
+	_, exists := c.entries[plate]
+	full := !exists && len(c.entries) >= c.MaxEntries
+	if full || c.sets%memorySweepEvery == 0 {
+		c.sweep(now)
+	}
+	if full && len(c.entries) >= c.MaxEntries {
+		c.evict(max(c.MaxEntries/10, 1))
+	}
+
+	c.entries[plate] = memoryCacheEntry{
+		info:      *info,
+		expiresAt: now.Add(ttl),
+	}
+}
+
+// sweep drops the expired entries, c.mu must be held.
+func (c *MemoryMemberCache) sweep(now time.Time) {
+	for k, e := range c.entries {
+		if now.After(e.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// evict drops n entries, map iteration order picks them at random.
+// c.mu must be held.
+func (c *MemoryMemberCache) evict(n int) {
+	for k := range c.entries {
+		if n <= 0 {
+			return
+		}
+		delete(c.entries, k)
+		n--
+	}
+}
+
+func (c *MemoryMemberCache) Delete(ctx context.Context, plates ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, p := range plates {
+		delete(c.entries, platenorm.Clean(p))
+	}
+	return nil
+}
+
+func (c *MemoryMemberCache) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]memoryCacheEntry)
+	return nil
+}
+
+// DBMemberCache is shared by every API instance using the same database.
+type DBMemberCache struct {
+	DB *gorm.DB
+}
+
+func NewDBMemberCache(db *gorm.DB) *DBMemberCache {
+	return &DBMemberCache{DB: db}
+}
+
+func (c *DBMemberCache) Get(ctx context.Context, plate string) (*MemberInfo, bool) {
+	var entry model.MemberCacheEntry
+	err := c.DB.WithContext(ctx).
+		Where("plate = ? AND expires_at > ?", plate, time.Now()).
+		First(&entry).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("member cache get %s failed: %v", plate, err)
+		}
+		return nil, false
+	}
+
+	return &MemberInfo{
+		Category:   entry.Category,
+		MemberID:   entry.MemberID,
+		ValidFrom:  entry.ValidFrom,
+		ValidUntil: entry.ValidUntil,
+	}, true
+}
+
+func (c *DBMemberCache) Set(ctx context.Context, plate string, info *MemberInfo, ttl time.Duration) {
+	entry := model.MemberCacheEntry{
+		Plate:      plate,
+		Category:   info.Category,
+		MemberID:   info.MemberID,
+		ValidFrom:  info.ValidFrom,
+		ValidUntil: info.ValidUntil,
+		ExpiresAt:  time.Now().Add(ttl),
+	}
+
+	err := c.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(&entry).Error
+	if err != nil {
+		log.Printf("member cache set %s failed: %v", plate, err)
+	}
+}
+
+// Prune drops the expired entries, non-members included, so plates
+// don't outlive their cache TTL.
+func (c *DBMemberCache) Prune(ctx context.Context) (int64, error) {
+	res := c.DB.WithContext(ctx).
+		Where("expires_at <= ?", time.Now()).
+		Delete(&model.MemberCacheEntry{})
+	return res.RowsAffected, res.Error
+}
+
+// Start prunes expired entries every interval until ctx is cancelled.
+func (c *DBMemberCache) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := c.Prune(ctx); err != nil {
+					log.Printf("member cache prune failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+func (c *DBMemberCache) Delete(ctx context.Context, plates ...string) error {
+	keys := make([]string, 0, len(plates))
+	for _, p := range plates {
+		keys = append(keys, platenorm.Clean(p))
+	}
+
+	return c.DB.WithContext(ctx).
+		Where("plate IN ?", keys).
+		Delete(&model.MemberCacheEntry{}).Error
+}
+
+func (c *DBMemberCache) Flush(ctx context.Context) error {
+	return c.DB.WithContext(ctx).
+		Where("1 = 1").
+		Delete(&model.MemberCacheEntry{}).Error
+}
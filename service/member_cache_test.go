@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// recordingCache is a MemberCache remembering the TTL of every Set.
+type recordingCache struct {
+	*MemoryMemberCache
+	ttls map[string]time.Duration
+}
+
+func (c *recordingCache) Set(ctx context.Context, plate string, info *MemberInfo, ttl time.Duration) {
+	c.ttls[plate] = ttl
+	c.MemoryMemberCache.Set(ctx, plate, info, ttl)
+}
+
+// countingMemberClient counts the lookups reaching the backend.
+type countingMemberClient struct {
+	StubMemberClient
+	calls int
+}
+
+func (m *countingMemberClient) CheckPlate(ctx context.Context, plate string) (*MemberInfo, error) {
+	m.calls++
+	return m.StubMemberClient.CheckPlate(ctx, plate)
+}
+
+func TestCachedMemberClientTTL(t *testing.T) {
+	soon := time.Now().Add(time.Minute)
+	tests := []struct {
+		name    string
+		members map[string]MemberInfo
+		err     error
+		wantTTL time.Duration
+		cached  bool
+	}{
+		{
+			name:    "member",
+			members: map[string]MemberInfo{"B1234XYZ": {Category: "MEMBER"}},
+			wantTTL: time.Hour,
+			cached:  true,
+		},
+		{
+			name:    "non-member",
+			wantTTL: 5 * time.Minute,
+			cached:  true,
+		},
+		{
+			name:    "membership expiring before the TTL",
+			members: map[string]MemberInfo{"B1234XYZ": {Category: "MEMBER", ValidUntil: &soon}},
+			wantTTL: time.Minute,
+			cached:  true,
+		},
+		{
+			name: "lookup error",
+			err:  errors.New("member service down"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &countingMemberClient{StubMemberClient: StubMemberClient{Members: tt.members, Err: tt.err}}
+			cache := &recordingCache{NewMemoryMemberCache(10), map[string]time.Duration{}}
+			m := NewCachedMemberClient(next, cache, time.Hour, 5*time.Minute)
+
+			// Both spellings share the cleaned key
+			for _, plate := range []string{"B1234XYZ", "b 1234-xyz"} {
+				_, err := m.CheckPlate(context.Background(), plate)
+				if (err != nil) != (tt.err != nil) {
+					t.Fatalf("CheckPlate(%s) err = %v, want %v", plate, err, tt.err)
+				}
+			}
+
+			ttl, ok := cache.ttls["B1234XYZ"]
+			if ok != tt.cached {
+				t.Fatalf("cached = %v, want %v", ok, tt.cached)
+			}
+			if tt.cached {
+				if diff := tt.wantTTL - ttl; diff < 0 || diff > time.Second {
+					t.Errorf("ttl = %s, want %s", ttl, tt.wantTTL)
+				}
+				if next.calls != 1 {
+					t.Errorf("backend lookups = %d, want 1", next.calls)
+				}
+			} else if next.calls != 2 {
+				t.Errorf("backend lookups = %d, want 2, failures are not cached", next.calls)
+			}
+		})
+	}
+}
+
+func TestMemoryMemberCacheExpiry(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryMemberCache(10)
+
+	c.Set(ctx, "B1", &MemberInfo{Category: "MEMBER"}, time.Hour)
+	c.Set(ctx, "B2", &MemberInfo{Category: "MEMBER"}, -time.Second)
+
+	if info, ok := c.Get(ctx, "B1"); !ok || info.Category != "MEMBER" {
+		t.Errorf("Get(B1) = %+v, %v, want MEMBER", info, ok)
+	}
+	if info, ok := c.Get(ctx, "B2"); ok {
+		t.Errorf("Get(B2) = %+v, want expired", info)
+	}
+
+	// Callers get a copy, not the cached entry
+	info, _ := c.Get(ctx, "B1")
+	info.Category = "CASUAL"
+	if info, _ := c.Get(ctx, "B1"); info.Category != "MEMBER" {
+		t.Errorf("cached entry changed through Get to %s", info.Category)
+	}
+}
+
+func TestMemoryMemberCacheSweep(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryMemberCache(10 * memorySweepEvery)
+
+	c.Set(ctx, "EXPIRED", &MemberInfo{}, -time.Second)
+	for i := 1; i < memorySweepEvery; i++ {
+		c.Set(ctx, "LIVE", &MemberInfo{}, time.Hour)
+	}
+	if len(c.entries) != 1 {
+		t.Fatalf("%d entries after the sweep, want 1", len(c.entries))
+	}
+	if _, ok := c.entries["EXPIRED"]; ok {
+		t.Error("expired entry survived the sweep")
+	}
+}
+
+func TestMemoryMemberCacheMaxEntries(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryMemberCache(20)
+
+	// Expired entries go first
+	for i := range 20 {
+		c.Set(ctx, fmt.Sprintf("OLD%d", i), &MemberInfo{}, -time.Second)
+	}
+	c.Set(ctx, "NEW", &MemberInfo{}, time.Hour)
+	if len(c.entries) != 1 {
+		t.Errorf("%d entries, want only NEW after dropping the expired ones", len(c.entries))
+	}
+
+	// Live entries are evicted a tenth at a time, never past the limit
+	for i := range 100 {
+		c.Set(ctx, fmt.Sprintf("B%d", i), &MemberInfo{}, time.Hour)
+		if len(c.entries) > c.MaxEntries {
+			t.Fatalf("%d entries, limit %d", len(c.entries), c.MaxEntries)
+		}
+	}
+	if _, ok := c.Get(ctx, "B99"); !ok {
+		t.Error("the latest entry was evicted")
+	}
+
+	// Overwriting a cached plate in a full cache evicts nothing
+	for len(c.entries) < c.MaxEntries {
+		c.Set(ctx, fmt.Sprintf("F%d", len(c.entries)), &MemberInfo{}, time.Hour)
+	}
+	c.Set(ctx, "B99", &MemberInfo{Category: "MEMBER"}, time.Hour)
+	if len(c.entries) != c.MaxEntries {
+		t.Errorf("%d entries after an overwrite, want %d", len(c.entries), c.MaxEntries)
+	}
+}
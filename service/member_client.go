@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"plate-recognizer-api/config"
+)
+
+// MemberInfo is the membership status of a plate.
+// An empty Category means the plate is not a member.
+type MemberInfo struct {
+	Category   string     `json:"category"`
+	MemberID   string     `json:"member_id,omitempty"`
+	ValidFrom  *time.Time `json:"valid_from,omitempty"`
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
+}
+
+// MemberClient looks up the membership of a plate.
+type MemberClient interface {
+	CheckPlate(ctx context.Context, plate string) (*MemberInfo, error)
+}
+
+// NewMemberClient builds the client selected by MEMBER_CLIENT.
+// Supported values: http (default) and stub.
+func NewMemberClient(env *config.Env) (MemberClient, error) {
+	switch strings.ToLower(env.MemberClient) {
+	case "", "http":
+		return NewHTTPMemberClient(
+			env.MemberServiceURL,
+			env.MemberServiceAuthHeader,
+			env.MemberServiceAuthToken,
+			env.MemberServiceTimeout,
+			env.MemberServiceRetries,
+		), nil
+	case "stub":
+		return &StubMemberClient{}, nil
+	default:
+		return nil, fmt.Errorf("unknown member client %q", env.MemberClient)
+	}
+}
+
+type memberCheckResponse struct {
+	Data struct {
+		Category   string      `json:"category"`
+		MemberID   interface{} `json:"member_id"`
+		ValidFrom  string      `json:"valid_from"`
+		ValidUntil string      `json:"valid_until"`
+	} `json:"data"`
+}
+
+// HTTPMemberClient calls the backend check-plat endpoint.
+type HTTPMemberClient struct {
+	BaseURL    string
+	AuthHeader string
+	AuthToken  string
+	Retries    int
+	Backoff    time.Duration
+	Client     *http.Client
+}
+
+func NewHTTPMemberClient(
+	baseURL, authHeader, authToken string,
+	timeout time.Duration,
+	retries int,
+) *HTTPMemberClient {
+	if authHeader == "" {
+		authHeader = "Authorization"
+	}
+	if timeout <= 0 {
+		timeout = 3 * time.Second
+	}
+
+	return &HTTPMemberClient{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		AuthHeader: authHeader,
+		AuthToken:  authToken,
+		Retries:    retries,
+		Backoff:    200 * time.Millisecond,
+		Client: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+// errRetryable marks failures worth another attempt.
+type errRetryable struct{ err error }
+
+func (e errRetryable) Error() string { return e.err.Error() }
+func (e errRetryable) Unwrap() error { return e.err }
+
+// IsRetryableMemberError reports whether a failed lookup may succeed
+// later: network errors, timeouts and 5xx answers, not 4xx answers.
+func IsRetryableMemberError(err error) bool {
+	var retryable errRetryable
+	return errors.As(err, &retryable) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, context.Canceled)
+}
+
+func (m *HTTPMemberClient) CheckPlate(ctx context.Context, plate string) (*MemberInfo, error) {
+	var lastErr error
+
+	for attempt := 0; attempt <= m.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(m.Backoff * time.Duration(attempt)):
+			}
+		}
+
+		info, err := m.check(ctx, plate)
+		if err == nil {
+			return info, nil
+		}
+		lastErr = err
+
+		var retryable errRetryable
+		if !errors.As(err, &retryable) || ctx.Err() != nil {
+			break
+		}
+		log.Printf("member check for %s failed (attempt %d): %v", plate, attempt+1, err)
+	}
+
+	return nil, lastErr
+}
+
+func (m *HTTPMemberClient) check(ctx context.Context, plate string) (*MemberInfo, error) {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodGet,
+		m.BaseURL+"/api/members/check-plat/"+url.PathEscape(plate),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.AuthToken != "" {
+		req.Header.Set(m.AuthHeader, m.AuthToken)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := m.Client.Do(req)
+	if err != nil {
+		return nil, errRetryable{err}
+	}
+	defer resp.Body.Close()
+
+	log.Println("Member service HTTP status:", resp.StatusCode)
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return nil, errRetryable{fmt.Errorf("member service returned %d", resp.StatusCode)}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("member service returned %d", resp.StatusCode)
+	}
+
+	// Numbers stay json.Number, a float64 member_id prints as 1.2e+06
+	var body memberCheckResponse
+	dec := json.NewDecoder(resp.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&body); err != nil {
+		log.Println("JSON decode error:", err)
+		return nil, err
+	}
+
+	info := &MemberInfo{
+		Category:   body.Data.Category,
+		ValidFrom:  parseMemberDate(body.Data.ValidFrom),
+		ValidUntil: parseMemberDate(body.Data.ValidUntil),
+	}
+	// member_id is numeric on some backends and a string on others
+	if body.Data.MemberID != nil {
+		info.MemberID = fmt.Sprint(body.Data.MemberID)
+	}
+
+	return info, nil
+}
+
+func parseMemberDate(v string) *time.Time {
+	if v == "" {
+		return nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return &t
+		}
+	}
+
+	log.Printf("member service: unparseable date %q", v)
+	return nil
+}
+
+// StubMemberClient answers from an in-memory table, for tests and for
+// sites without a member backend.
+type StubMemberClient struct {
+	Members map[string]MemberInfo
+	Err     error
+}
+
+func (m *StubMemberClient) CheckPlate(ctx context.Context, plate string) (*MemberInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if m.Err != nil {
+		return nil, m.Err
+	}
+
+	if info, ok := m.Members[plate]; ok {
+		return &info, nil
+	}
+	return &MemberInfo{}, nil
+}
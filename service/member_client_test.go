@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHTTPMemberClientCheckPlate(t *testing.T) {
+	tests := []struct {
+		name     string
+		plate    string
+		statuses []int
+		body     string
+
+		wantPath     string
+		wantAttempts int32
+		wantErr      bool
+		wantRetry    bool
+		wantInfo     MemberInfo
+		wantFrom     string
+		wantUntil    string
+	}{
+		{
+			name:         "member with string id",
+			plate:        "B1234XYZ",
+			body:         `{"data":{"category":"MEMBER","member_id":"m-42","valid_from":"2026-01-01T00:00:00Z","valid_until":"2026-12-31T23:59:59Z"}}`,
+			wantPath:     "/api/members/check-plat/B1234XYZ",
+			wantAttempts: 1,
+			wantInfo:     MemberInfo{Category: "MEMBER", MemberID: "m-42"},
+			wantFrom:     "2026-01-01T00:00:00Z",
+			wantUntil:    "2026-12-31T23:59:59Z",
+		},
+		{
+			name:         "numeric id and plain dates",
+			plate:        "B1234XYZ",
+			body:         `{"data":{"category":"VIP","member_id":1234567,"valid_from":"2026-01-01 08:30:00","valid_until":"2026-06-30"}}`,
+			wantPath:     "/api/members/check-plat/B1234XYZ",
+			wantAttempts: 1,
+			wantInfo:     MemberInfo{Category: "VIP", MemberID: "1234567"},
+			wantFrom:     "2026-01-01T08:30:00Z",
+			wantUntil:    "2026-06-30T00:00:00Z",
+		},
+		{
+			name:         "not a member",
+			plate:        "B1234XYZ",
+			body:         `{"data":{"category":"","member_id":null,"valid_until":"soon"}}`,
+			wantPath:     "/api/members/check-plat/B1234XYZ",
+			wantAttempts: 1,
+		},
+		{
+			name:         "plate is escaped",
+			plate:        "B 12/34?x",
+			body:         `{"data":{}}`,
+			wantPath:     "/api/members/check-plat/B%2012%2F34%3Fx",
+			wantAttempts: 1,
+		},
+		{
+			name:         "5xx is retried",
+			plate:        "B1234XYZ",
+			statuses:     []int{http.StatusBadGateway},
+			body:         `{"data":{"category":"MEMBER"}}`,
+			wantPath:     "/api/members/check-plat/B1234XYZ",
+			wantAttempts: 2,
+			wantInfo:     MemberInfo{Category: "MEMBER"},
+		},
+		{
+			name:         "5xx gives up after the retries",
+			plate:        "B1234XYZ",
+			statuses:     []int{500, 503, 500},
+			wantPath:     "/api/members/check-plat/B1234XYZ",
+			wantAttempts: 3,
+			wantErr:      true,
+			wantRetry:    true,
+		},
+		{
+			name:         "4xx is not retried",
+			plate:        "B1234XYZ",
+			statuses:     []int{http.StatusNotFound},
+			wantPath:     "/api/members/check-plat/B1234XYZ",
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "bad json",
+			plate:        "B1234XYZ",
+			body:         `{"data":`,
+			wantPath:     "/api/members/check-plat/B1234XYZ",
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := attempts.Add(1)
+				if got := r.URL.EscapedPath(); got != tt.wantPath {
+					t.Errorf("path = %s, want %s", got, tt.wantPath)
+				}
+				if got := r.Header.Get("X-Member-Token"); got != "secret" {
+					t.Errorf("auth header = %q, want secret", got)
+				}
+				if int(n) <= len(tt.statuses) {
+					w.WriteHeader(tt.statuses[n-1])
+					return
+				}
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			m := NewHTTPMemberClient(srv.URL+"/", "X-Member-Token", "secret", time.Second, 2)
+			m.Backoff = time.Millisecond
+
+			info, err := m.CheckPlate(context.Background(), tt.plate)
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("CheckPlate = %+v, want error", info)
+				}
+				if got := IsRetryableMemberError(err); got != tt.wantRetry {
+					t.Errorf("retryable = %v, want %v (%v)", got, tt.wantRetry, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if info.Category != tt.wantInfo.Category || info.MemberID != tt.wantInfo.MemberID {
+				t.Errorf("info = %+v, want %+v", info, tt.wantInfo)
+			}
+			checkDate(t, "valid_from", info.ValidFrom, tt.wantFrom)
+			checkDate(t, "valid_until", info.ValidUntil, tt.wantUntil)
+		})
+	}
+}
+
+func TestHTTPMemberClientNetworkError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	m := NewHTTPMemberClient(srv.URL, "", "", time.Second, 1)
+	m.Backoff = time.Millisecond
+
+	_, err := m.CheckPlate(context.Background(), "B1234XYZ")
+	if err == nil || !IsRetryableMemberError(err) {
+		t.Errorf("CheckPlate on a closed server = %v, want a retryable error", err)
+	}
+}
+
+func checkDate(t *testing.T, name string, got *time.Time, want string) {
+	t.Helper()
+
+	if want == "" {
+		if got != nil {
+			t.Errorf("%s = %s, want none", name, got)
+		}
+		return
+	}
+	if got == nil || got.UTC().Format(time.RFC3339) != want {
+		t.Errorf("%s = %v, want %s", name, got, want)
+	}
+}
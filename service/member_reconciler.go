@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"plate-recognizer-api/model"
+
+	"gorm.io/gorm"
+)
+
+// MemberFailurePolicy decides what happens to a recognition when the
+// member lookup fails.
+type MemberFailurePolicy struct {
+	// Degraded serves the gate with FallbackCategory instead of failing
+	Degraded         bool
+	FallbackCategory string
+}
+
+// MemberReconciler retries member lookups that failed in degraded mode
+// and writes the resolved membership back to the plate log. Lookups
+// failing permanently, or MaxAttempts times, are marked FAILED.
+type MemberReconciler struct {
+	DB          *gorm.DB
+	Members     MemberClient
+	Interval    time.Duration
+	Batch       int
+	MaxAttempts int
+}
+
+func NewMemberReconciler(db *gorm.DB, members MemberClient, interval time.Duration) *MemberReconciler {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	return &MemberReconciler{
+		DB:          db,
+		Members:     members,
+		Interval:    interval,
+		Batch:       50,
+		MaxAttempts: 20,
+	}
+}
+
+// Enqueue records a failed lookup for the given plate log.
+func (r *MemberReconciler) Enqueue(
+	ctx context.Context,
+	plateLog *model.PlateLog,
+	fallbackCategory string,
+	lookupErr error,
+) error {
+	now := time.Now()
+
+	return r.DB.WithContext(ctx).Create(&model.MemberReconciliation{
+		PlateLogID:       plateLog.ID,
+		Plate:            plateLog.Plate,
+		LocationCode:     plateLog.LocationCode,
+		FallbackCategory: fallbackCategory,
+		Status:           model.ReconciliationPending,
+		Attempts:         1,
+		LastError:        lookupErr.Error(),
+		NextAttemptAt:    now.Add(r.Interval),
+	}).Error
+}
+
+// Start runs the reconciliation loop until ctx is cancelled.
+func (r *MemberReconciler) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(r.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.runOnce(ctx)
+			}
+		}
+	}()
+}
+
+func (r *MemberReconciler) runOnce(ctx context.Context) {
+	var pending []model.MemberReconciliation
+	if err := r.DB.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", model.ReconciliationPending, time.Now()).
+		Order("next_attempt_at").
+		Limit(r.Batch).
+		Find(&pending).Error; err != nil {
+		log.Printf("member reconciliation: load pending failed: %v", err)
+		return
+	}
+
+	for i := range pending {
+		if ctx.Err() != nil {
+			return
+		}
+		r.reconcile(ctx, &pending[i])
+	}
+}
+
+func (r *MemberReconciler) reconcile(ctx context.Context, item *model.MemberReconciliation) {
+	db := r.DB.WithContext(ctx)
+	attempts := item.Attempts + 1
+
+	member, err := r.Members.CheckPlate(ctx, item.Plate)
+	if err != nil {
+		updates := map[string]interface{}{
+			"attempts":   attempts,
+			"last_error": err.Error(),
+		}
+
+		if !IsRetryableMemberError(err) || (r.MaxAttempts > 0 && attempts >= r.MaxAttempts) {
+			log.Printf("member reconciliation: giving up on plate %s (log %d) after %d attempts: %v", item.Plate, item.PlateLogID, attempts, err)
+			updates["status"] = model.ReconciliationFailed
+		} else {
+			// Exponential backoff capped at one hour
+			delay := r.Interval << min(item.Attempts, 6)
+			if delay > time.Hour {
+				delay = time.Hour
+			}
+			updates["next_attempt_at"] = time.Now().Add(delay)
+		}
+
+		if err := db.Model(item).Updates(updates).Error; err != nil {
+			log.Printf("member reconciliation: update %d failed: %v", item.ID, err)
+		}
+		return
+	}
+
+	category := member.Category
+	if category == "" {
+		category = "CASUAL"
+	}
+
+	if category != item.FallbackCategory {
+		log.Printf(
+			"member reconciliation: plate %s (log %d) was served as %s but is %s",
+			item.Plate,
+			item.PlateLogID,
+			item.FallbackCategory,
+			category,
+		)
+	}
+
+	now := time.Now()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.PlateLog{}).
+			Where("id = ?", item.PlateLogID).
+			Updates(map[string]interface{}{
+				"member_category": category,
+				"member_id":       member.MemberID,
+				"member_degraded": false,
+			}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(item).Updates(map[string]interface{}{
+			"status":      model.ReconciliationResolved,
+			"attempts":    attempts,
+			"category":    category,
+			"member_id":   member.MemberID,
+			"resolved_at": &now,
+		}).Error
+	})
+	if err != nil {
+		log.Printf("member reconciliation: resolve %d failed: %v", item.ID, err)
+	}
+}
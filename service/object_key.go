@@ -0,0 +1,113 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"plate-recognizer-api/model"
+)
+
+// DefaultObjectKeyTemplate lays images out by site, day and camera. The
+// log id keeps a repeated transaction_no from overwriting an image that
+// an earlier log still references.
+const DefaultObjectKeyTemplate = "{location}/{yyyy}/{mm}/{dd}/{camera}/{transaction_no}-{id}{ext}"
+
+// imageExtensions maps the detected content type to {ext}.
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+	"image/gif":  ".gif",
+	"image/bmp":  ".bmp",
+}
+
+var (
+	objectKeyToken  = regexp.MustCompile(`\{[a-z_]+\}`)
+	objectKeyUnsafe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+var objectKeyTokens = map[string]func(l *model.PlateLog) string{
+	"{location}":       func(l *model.PlateLog) string { return l.LocationCode },
+	"{camera}":         func(l *model.PlateLog) string { return l.CameraID },
+	"{transaction_no}": transactionKey,
+	"{plate}":          func(l *model.PlateLog) string { return l.Plate },
+	"{id}":             func(l *model.PlateLog) string { return strconv.FormatUint(uint64(l.ID), 10) },
+	"{yyyy}":           func(l *model.PlateLog) string { return l.Timestamp.Format("2006") },
+	"{mm}":             func(l *model.PlateLog) string { return l.Timestamp.Format("01") },
+	"{dd}":             func(l *model.PlateLog) string { return l.Timestamp.Format("02") },
+	"{hh}":             func(l *model.PlateLog) string { return l.Timestamp.Format("15") },
+	"{unix}":           func(l *model.PlateLog) string { return strconv.FormatInt(l.Timestamp.Unix(), 10) },
+}
+
+// transactionKey falls back to the log id so gates that don't send a
+// transaction number never overwrite each other's images.
+func transactionKey(l *model.PlateLog) string {
+	if l.TransactionNo != "" {
+		return l.TransactionNo
+	}
+	return fmt.Sprintf("log-%d", l.ID)
+}
+
+// ObjectKeyTemplate renders object names such as
+// "{location}/{yyyy}/{mm}/{dd}/{camera}/{transaction_no}-{id}{ext}".
+// Templates without {id} may overwrite the image of an earlier log that
+// repeated the transaction number, they are accepted with a warning.
+type ObjectKeyTemplate struct {
+	template string
+}
+
+func NewObjectKeyTemplate(template string) (*ObjectKeyTemplate, error) {
+	if template == "" {
+		template = DefaultObjectKeyTemplate
+	}
+
+	for _, token := range objectKeyToken.FindAllString(template, -1) {
+		if _, ok := objectKeyTokens[token]; !ok && token != "{ext}" {
+			return nil, fmt.Errorf("unknown object key token %s", token)
+		}
+	}
+	if !strings.Contains(template, "{id}") {
+		log.Printf("object key template %q has no {id}, a repeated transaction_no overwrites earlier images", template)
+	}
+
+	return &ObjectKeyTemplate{template: template}, nil
+}
+
+// Render names the object of a log image, {ext} is taken from its
+// content type.
+func (t *ObjectKeyTemplate) Render(plateLog *model.PlateLog, contentType string) string {
+	key := objectKeyToken.ReplaceAllStringFunc(t.template, func(token string) string {
+		if token == "{ext}" {
+			return imageExtension(contentType)
+		}
+
+		value := objectKeyTokens[token](plateLog)
+		// Values become path segments, never let them add new ones
+		value = objectKeyUnsafe.ReplaceAllString(value, "_")
+		value = strings.TrimLeft(value, ".")
+		if value == "" {
+			value = "unknown"
+		}
+		return value
+	})
+
+	return strings.TrimLeft(key, "/")
+}
+
+func imageExtension(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	if ext, ok := imageExtensions[mediaType]; ok {
+		return ext
+	}
+	if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type openALPRResponse struct {
+	Results []struct {
+		Plate            string  `json:"plate"`
+		Confidence       float64 `json:"confidence"`
+		Region           string  `json:"region"`
+		RegionConfidence float64 `json:"region_confidence"`
+		Coordinates      []struct {
+			X int `json:"x"`
+			Y int `json:"y"`
+		} `json:"coordinates"`
+		Candidates []struct {
+			Plate      string  `json:"plate"`
+			Confidence float64 `json:"confidence"`
+		} `json:"candidates"`
+	} `json:"results"`
+}
+
+// OpenALPREngine talks to an OpenALPR-compatible HTTP API
+// (POST {base}/v3/recognize_bytes with a base64 body).
+type OpenALPREngine struct {
+	BaseURL   string
+	SecretKey string
+	Country   string
+	Client    *http.Client
+}
+
+func NewOpenALPREngine(baseURL, secretKey, country string) *OpenALPREngine {
+	if country == "" {
+		country = "id"
+	}
+
+	return &OpenALPREngine{
+		BaseURL:   strings.TrimRight(baseURL, "/"),
+		SecretKey: secretKey,
+		Country:   country,
+		Client: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}
+}
+
+func (e *OpenALPREngine) Recognize(
+	ctx context.Context,
+	image []byte,
+	opts RecognizeOptions,
+) (*Recognition, error) {
+	start := time.Now()
+
+	defer func() {
+		log.Println("⏱ OpenALPR duration:", time.Since(start))
+	}()
+
+	query := url.Values{}
+	query.Set("secret_key", e.SecretKey)
+	query.Set("country", e.Country)
+	query.Set("recognize_vehicle", "0")
+
+	// Keep the secret out of anything that gets logged or stored
+	publicURL := e.BaseURL + "/v3/recognize_bytes"
+	endpoint := publicURL + "?" + query.Encode()
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		endpoint,
+		bytes.NewBufferString(base64.StdEncoding.EncodeToString(image)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("🚀 OpenALPR REQUEST")
+	log.Println("URL       :", publicURL)
+	log.Println("Camera ID :", opts.CameraID)
+
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("📥 OpenALPR RESPONSE")
+	log.Println("Status :", resp.Status)
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("openalpr returned %d", resp.StatusCode)
+	}
+
+	var result openALPRResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, err
+	}
+
+	candidates := make([]Candidate, 0, len(result.Results))
+	for _, r := range result.Results {
+		// OpenALPR reports confidence as a percentage
+		c := Candidate{
+			Plate: r.Plate,
+			Score: r.Confidence / 100,
+		}
+
+		if r.Region != "" {
+			c.Region = &Region{Code: r.Region, Score: r.RegionConfidence / 100}
+		}
+
+		// Coordinates are the plate polygon corners
+		if len(r.Coordinates) > 0 {
+			box := Box{
+				XMin: r.Coordinates[0].X, YMin: r.Coordinates[0].Y,
+				XMax: r.Coordinates[0].X, YMax: r.Coordinates[0].Y,
+			}
+			for _, p := range r.Coordinates[1:] {
+				box.XMin = min(box.XMin, p.X)
+				box.YMin = min(box.YMin, p.Y)
+				box.XMax = max(box.XMax, p.X)
+				box.YMax = max(box.YMax, p.Y)
+			}
+			c.Box = &box
+		}
+
+		for _, alt := range r.Candidates {
+			c.Alternates = append(c.Alternates, PlateCandidate{
+				Plate: alt.Plate,
+				Score: alt.Confidence / 100,
+			})
+		}
+
+		candidates = append(candidates, c)
+	}
+
+	return &Recognition{
+		Candidates: candidates,
+		Raw:        respBody,
+		EngineURL:  publicURL,
+		Latency:    time.Since(start),
+		HTTPStatus: resp.StatusCode,
+	}, nil
+}
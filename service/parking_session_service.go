@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"plate-recognizer-api/internal/platematch"
+	"plate-recognizer-api/internal/platenorm"
+	"plate-recognizer-api/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	DirectionEntry = "entry"
+	DirectionExit  = "exit"
+)
+
+// ParkingSessionService opens a session on an entry read and closes it on
+// the matching exit read (same plate and location).
+type ParkingSessionService struct {
+	DB *gorm.DB
+
+	// Repeated entry reads of the same vehicle within this window are
+	// treated as one (camera re-triggered, gate retried).
+	DuplicateWindow time.Duration
+
+	// Optional fuzzy pairing of exit reads without an exact open session
+	Matcher *platematch.Matcher
+}
+
+// maxFuzzySessions bounds the open sessions compared to one exit read.
+const maxFuzzySessions = 1000
+
+func NewParkingSessionService(db *gorm.DB, duplicateWindow time.Duration) *ParkingSessionService {
+	return &ParkingSessionService{
+		DB:              db,
+		DuplicateWindow: duplicateWindow,
+	}
+}
+
+// RecordRead applies a recognition to the sessions of its location.
+// The engine alternates help pairing an exit read with a misread entry.
+// It returns nil without error for an exit with no open session.
+func (s *ParkingSessionService) RecordRead(
+	ctx context.Context,
+	plateLog *model.PlateLog,
+	direction string,
+	alternates []platematch.Alternate,
+) (*model.ParkingSession, error) {
+	switch strings.ToLower(direction) {
+	case DirectionEntry:
+		return s.enter(ctx, plateLog)
+	case DirectionExit:
+		return s.exit(ctx, plateLog, alternates)
+	default:
+		return nil, fmt.Errorf("unknown direction %q", direction)
+	}
+}
+
+// lockPlate serializes the reads of one plate at one location until the
+// transaction ends. A row lock can't do it, there is no row to lock
+// before the first entry creates one.
+func lockPlate(tx *gorm.DB, locationCode, plate string) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", locationCode+"|"+plate).Error
+}
+
+// findOpen must run after lockPlate.
+func (s *ParkingSessionService) findOpen(tx *gorm.DB, locationCode, plate string) (*model.ParkingSession, error) {
+	var session model.ParkingSession
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("location_code = ? AND plate = ? AND status = ?", locationCode, plate, model.SessionOpen).
+		Order("entry_at DESC").
+		First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (s *ParkingSessionService) enter(ctx context.Context, plateLog *model.PlateLog) (*model.ParkingSession, error) {
+	var result *model.ParkingSession
+
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockPlate(tx, plateLog.LocationCode, plateLog.Plate); err != nil {
+			return err
+		}
+		open, err := s.findOpen(tx, plateLog.LocationCode, plateLog.Plate)
+		if err != nil {
+			return err
+		}
+
+		if open != nil {
+			if plateLog.Timestamp.Sub(open.EntryAt) < s.DuplicateWindow {
+				result = open
+				return nil
+			}
+			// The vehicle left unseen, don't let the old session run forever
+			if err := tx.Model(open).Update("status", model.SessionSuperseded).Error; err != nil {
+				return err
+			}
+		}
+
+		session := &model.ParkingSession{
+			LocationCode:  plateLog.LocationCode,
+			Plate:         plateLog.Plate,
+			Status:        model.SessionOpen,
+			EntryLogID:    plateLog.ID,
+			EntryCameraID: plateLog.CameraID,
+			EntryAt:       plateLog.Timestamp,
+		}
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+
+		result = session
+		return nil
+	})
+
+	return result, err
+}
+
+func (s *ParkingSessionService) exit(
+	ctx context.Context,
+	plateLog *model.PlateLog,
+	alternates []platematch.Alternate,
+) (*model.ParkingSession, error) {
+	var result *model.ParkingSession
+
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockPlate(tx, plateLog.LocationCode, plateLog.Plate); err != nil {
+			return err
+		}
+		open, err := s.findOpen(tx, plateLog.LocationCode, plateLog.Plate)
+		if err != nil {
+			return err
+		}
+		if open == nil {
+			if open, err = s.findSimilarOpen(tx, plateLog, alternates); err != nil || open == nil {
+				return err
+			}
+		}
+		// A session opened after the read isn't the one it leaves
+		if open.EntryAt.After(plateLog.Timestamp) {
+			return nil
+		}
+
+		exitAt := plateLog.Timestamp
+		exitLogID := plateLog.ID
+		open.Status = model.SessionClosed
+		open.ExitLogID = &exitLogID
+		open.ExitCameraID = plateLog.CameraID
+		open.ExitAt = &exitAt
+		open.DurationSeconds = int64(exitAt.Sub(open.EntryAt).Seconds())
+
+		if err := tx.Save(open).Error; err != nil {
+			return err
+		}
+
+		result = open
+		return nil
+	})
+
+	return result, err
+}
+
+// findSimilarOpen returns the open session of the location whose plate
+// best matches the exit read, if the Matcher accepts it. Candidates are
+// read without locking, only the chosen session is locked and checked
+// to be still open, so exits at a site don't queue behind each other.
+func (s *ParkingSessionService) findSimilarOpen(
+	tx *gorm.DB,
+	plateLog *model.PlateLog,
+	alternates []platematch.Alternate,
+) (*model.ParkingSession, error) {
+	if s.Matcher == nil {
+		return nil, nil
+	}
+
+	var candidates []model.ParkingSession
+	if err := tx.Select("id", "plate").
+		Where("location_code = ? AND status = ?", plateLog.LocationCode, model.SessionOpen).
+		Order("entry_at DESC").
+		Limit(maxFuzzySessions).
+		Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	known := make([]string, len(candidates))
+	for i := range candidates {
+		known[i] = candidates[i].Plate
+	}
+
+	match, ok := s.Matcher.Best(plateLog.Plate, alternates, known)
+	if !ok {
+		return nil, nil
+	}
+
+	for i := range candidates {
+		if candidates[i].Plate != match.Plate {
+			continue
+		}
+
+		var session model.ParkingSession
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND status = ?", candidates[i].ID, model.SessionOpen).
+			First(&session).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Closed by a concurrent exit in the meantime
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		session.ExitPlate = plateLog.Plate
+		session.MatchSimilarity = &match.Similarity
+		return &session, nil
+	}
+	return nil, nil
+}
+
+// Current returns the open session of a plate at a location, with its
+// duration so far, or gorm.ErrRecordNotFound.
+func (s *ParkingSessionService) Current(ctx context.Context, locationCode, plate string) (*model.ParkingSession, error) {
+	var session model.ParkingSession
+	err := s.DB.WithContext(ctx).
+		Where("location_code = ? AND plate = ? AND status = ?", locationCode, platenorm.Clean(plate), model.SessionOpen).
+		Order("entry_at DESC").
+		First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+
+	session.DurationSeconds = int64(time.Since(session.EntryAt).Seconds())
+	return &session, nil
+}
+
+// ParkingSessionFilter narrows List. Zero values are ignored.
+type ParkingSessionFilter struct {
+	LocationCode string
+	Plate        string
+	Status       string
+	Limit        int
+
+	// Locations restricts results to these locations when not nil
+	Locations []string
+}
+
+// List returns the most recent sessions first.
+func (s *ParkingSessionService) List(ctx context.Context, f ParkingSessionFilter) ([]model.ParkingSession, error) {
+	tx := s.DB.WithContext(ctx).Model(&model.ParkingSession{})
+	if f.LocationCode != "" {
+		tx = tx.Where("location_code = ?", f.LocationCode)
+	}
+	if f.Locations != nil {
+		tx = tx.Where("location_code IN ?", f.Locations)
+	}
+	if f.Plate != "" {
+		tx = tx.Where("plate = ?", platenorm.Clean(f.Plate))
+	}
+	if f.Status != "" {
+		tx = tx.Where("status = ?", strings.ToUpper(f.Status))
+	}
+
+	limit := f.Limit
+	if limit <= 0 || limit > MaxPlateLogLimit {
+		limit = DefaultPlateLogLimit
+	}
+
+	var sessions []model.ParkingSession
+	if err := tx.Order("entry_at DESC").Limit(limit).Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	// Open sessions report how long the vehicle has been inside so far
+	now := time.Now()
+	for i := range sessions {
+		if sessions[i].Status == model.SessionOpen {
+			sessions[i].DurationSeconds = int64(now.Sub(sessions[i].EntryAt).Seconds())
+		}
+	}
+
+	return sessions, nil
+}
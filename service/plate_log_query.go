@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"plate-recognizer-api/internal/platenorm"
+	"plate-recognizer-api/model"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultPlateLogLimit = 50
+	MaxPlateLogLimit     = 500
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidSort   = errors.New("sort must be one of timestamp, -timestamp, id, -id")
+)
+
+// PlateLogFilter narrows plate log queries. Zero values are ignored.
+type PlateLogFilter struct {
+	LocationCode  string
+	CameraID      string
+	Plate         string
+	PlatePrefix   string
+	TransactionNo string
+	From          *time.Time
+	To            *time.Time
+	MinAccuracy   *float64
+	ReviewStatus  string
+
+	// Locations restricts results to these locations when not nil
+	Locations []string
+}
+
+// PlateLogQuery is one page request. Sort is "timestamp" or "id", with a
+// leading "-" for descending order (default "-timestamp").
+type PlateLogQuery struct {
+	Filter PlateLogFilter
+	Sort   string
+	Cursor string
+	Limit  int
+}
+
+type PlateLogPage struct {
+	Items      []model.PlateLog
+	NextCursor string
+}
+
+type plateLogCursor struct {
+	Timestamp time.Time `json:"t,omitempty"`
+	ID        uint      `json:"id"`
+}
+
+// ApplyPlateLogFilter adds the filter conditions to db.
+func ApplyPlateLogFilter(db *gorm.DB, f PlateLogFilter) *gorm.DB {
+	if f.LocationCode != "" {
+		db = db.Where("location_code = ?", f.LocationCode)
+	}
+	if f.Locations != nil {
+		db = db.Where("location_code IN ?", f.Locations)
+	}
+	if f.CameraID != "" {
+		db = db.Where("camera_id = ?", f.CameraID)
+	}
+	if f.Plate != "" {
+		db = db.Where("plate = ?", platenorm.Clean(f.Plate))
+	}
+	if f.PlatePrefix != "" {
+		db = db.Where("plate LIKE ?", escapeLike(platenorm.Clean(f.PlatePrefix))+"%")
+	}
+	if f.TransactionNo != "" {
+		db = db.Where("transaction_no = ?", f.TransactionNo)
+	}
+	if f.From != nil {
+		db = db.Where("timestamp >= ?", *f.From)
+	}
+	if f.To != nil {
+		db = db.Where("timestamp < ?", *f.To)
+	}
+	if f.ReviewStatus != "" {
+		db = db.Where("review_status = ?", strings.ToUpper(f.ReviewStatus))
+	}
+	if f.MinAccuracy != nil {
+		// accuracy is stored as text, e.g. "0.91"
+		db = db.Where("CAST(NULLIF(accuracy, '') AS numeric) >= ?", *f.MinAccuracy)
+	}
+	return db
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// ListPlateLogs returns one page of plate logs using keyset pagination.
+func ListPlateLogs(ctx context.Context, db *gorm.DB, q PlateLogQuery) (*PlateLogPage, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultPlateLogLimit
+	}
+	if limit > MaxPlateLogLimit {
+		limit = MaxPlateLogLimit
+	}
+
+	sort := q.Sort
+	if sort == "" {
+		sort = "-timestamp"
+	}
+	desc := strings.HasPrefix(sort, "-")
+	field := strings.TrimPrefix(sort, "-")
+	if field != "timestamp" && field != "id" {
+		return nil, ErrInvalidSort
+	}
+
+	dir, op := "ASC", ">"
+	if desc {
+		dir, op = "DESC", "<"
+	}
+
+	tx := ApplyPlateLogFilter(db.WithContext(ctx).Model(&model.PlateLog{}), q.Filter)
+
+	if q.Cursor != "" {
+		cur, err := decodePlateLogCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if field == "timestamp" {
+			tx = tx.Where("(timestamp, id) "+op+" (?, ?)", cur.Timestamp, cur.ID)
+		} else {
+			tx = tx.Where("id "+op+" ?", cur.ID)
+		}
+	}
+
+	if field == "timestamp" {
+		tx = tx.Order("timestamp " + dir)
+	}
+	tx = tx.Order("id " + dir)
+
+	// Fetch one extra row to know whether there is a next page
+	var items []model.PlateLog
+	if err := tx.Limit(limit + 1).Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	page := &PlateLogPage{Items: items}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		last := page.Items[limit-1]
+		cur := plateLogCursor{ID: last.ID}
+		if field == "timestamp" {
+			cur.Timestamp = last.Timestamp
+		}
+		page.NextCursor = encodePlateLogCursor(cur)
+	}
+
+	return page, nil
+}
+
+// GetPlateLog returns gorm.ErrRecordNotFound when id doesn't exist.
+func GetPlateLog(ctx context.Context, db *gorm.DB, id uint) (*model.PlateLog, error) {
+	var plateLog model.PlateLog
+	if err := db.WithContext(ctx).First(&plateLog, id).Error; err != nil {
+		return nil, err
+	}
+	return &plateLog, nil
+}
+
+func encodePlateLogCursor(c plateLogCursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodePlateLogCursor(s string) (plateLogCursor, error) {
+	var c plateLogCursor
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// EachPlateLog streams every log matching the filter, oldest first,
+// without loading the result set in memory.
+func EachPlateLog(ctx context.Context, db *gorm.DB, f PlateLogFilter, fn func(*model.PlateLog) error) error {
+	tx := ApplyPlateLogFilter(db.WithContext(ctx).Model(&model.PlateLog{}), f).
+		Order("timestamp ASC").
+		Order("id ASC")
+
+	rows, err := tx.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var plateLog model.PlateLog
+		if err := tx.ScanRows(rows, &plateLog); err != nil {
+			return err
+		}
+		if err := fn(&plateLog); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// MemberCategory is the member category of a log. Logs stored before
+// the member_category column only have it in ResponseFinal.
+func MemberCategory(l *model.PlateLog) string {
+	if l.MemberCategory != "" {
+		return l.MemberCategory
+	}
+	return MemberCategoryFromResponse(l.ResponseFinal)
+}
+
+// MemberCategoryFromResponse extracts status_member from a stored
+// ResponseFinal payload.
+func MemberCategoryFromResponse(responseFinal string) string {
+	var resp struct {
+		Data struct {
+			StatusMember string `json:"status_member"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(responseFinal), &resp); err != nil {
+		return ""
+	}
+	return resp.Data.StatusMember
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"plate-recognizer-api/internal/platenorm"
+	"plate-recognizer-api/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrNotPendingReview = errors.New("plate log is not pending review")
+
+// ReviewPlateLog settles a low-confidence read. An empty plate, or the
+// plate as read, confirms it; anything else corrects the log and keeps
+// the engine read in OriginalPlate.
+func ReviewPlateLog(ctx context.Context, db *gorm.DB, id uint, plate, reviewer string) (*model.PlateLog, error) {
+	var plateLog model.PlateLog
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&plateLog, id).Error; err != nil {
+			return err
+		}
+		if plateLog.ReviewStatus != model.ReviewPending {
+			return ErrNotPendingReview
+		}
+
+		now := time.Now()
+		plateLog.ReviewStatus = model.ReviewConfirmed
+		plateLog.ReviewedBy = reviewer
+		plateLog.ReviewedAt = &now
+
+		plate = platenorm.Clean(plate)
+		if plate != "" && plate != plateLog.Plate {
+			plateLog.ReviewStatus = model.ReviewCorrected
+			plateLog.OriginalPlate = plateLog.Plate
+			plateLog.Plate = plate
+		}
+
+		return tx.Model(&plateLog).
+			Select("review_status", "reviewed_by", "reviewed_at", "original_plate", "plate").
+			Updates(&plateLog).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &plateLog, nil
+}
+
+// Review settles a low-confidence read like ReviewPlateLog, then runs
+// the steps the read skipped at ingest: the member lookup and the
+// parking sessions. A corrected plate is normalized like an engine read
+// of the same camera.
+func (s *PlateLogService) Review(ctx context.Context, id uint, plate, reviewer string) (*model.PlateLog, error) {
+	var current model.PlateLog
+	if err := s.DB.WithContext(ctx).First(&current, id).Error; err != nil {
+		return nil, err
+	}
+
+	country, direction := s.Country, ""
+	if s.Cameras != nil {
+		gate, err := s.Cameras.Resolve(ctx, current.LocationCode, current.CameraID)
+		if err != nil {
+			log.Printf("review of log %d: camera %s/%s: %v", id, current.LocationCode, current.CameraID, err)
+		} else {
+			if c := gate.Country(); c != "" {
+				country = c
+			}
+			direction = gate.Camera.Direction
+		}
+	}
+
+	rules := platenorm.For(country)
+	if plate = platenorm.Clean(plate); plate != "" {
+		plate = rules.Normalize(plate).Normalized
+	}
+
+	plateLog, err := ReviewPlateLog(ctx, s.DB, id, plate, reviewer)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := s.Budget.Request(ctx)
+	defer cancel()
+
+	// The review stands even when the member service is down
+	member, err := s.checkMember(ctx, rules, plateLog.Plate, nil)
+	if err != nil {
+		log.Printf("review of log %d: member lookup failed: %v", id, err)
+	} else {
+		member.setOn(plateLog)
+		if err := s.DB.WithContext(ctx).
+			Model(plateLog).
+			Select("member_category", "member_id", "member_degraded").
+			Updates(plateLog).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// Sessions moved on while the read sat in the queue, pairing it now
+	// would close or open the wrong one
+	if direction != "" {
+		later, err := s.hasLaterRead(ctx, plateLog)
+		if err != nil {
+			log.Printf("review of log %d: later reads: %v", id, err)
+		}
+		if later || err != nil {
+			direction = ""
+		}
+	}
+
+	s.afterSave(ctx, plateLog, direction, nil, member, map[string]interface{}{})
+	return plateLog, nil
+}
+
+// hasLaterRead reports whether the plate was read again at the location
+// after plateLog.
+func (s *PlateLogService) hasLaterRead(ctx context.Context, plateLog *model.PlateLog) (bool, error) {
+	var ids []uint
+	err := s.DB.WithContext(ctx).
+		Model(&model.PlateLog{}).
+		Where("location_code = ? AND plate = ? AND timestamp > ? AND id <> ?",
+			plateLog.LocationCode, plateLog.Plate, plateLog.Timestamp, plateLog.ID).
+		Limit(1).
+		Pluck("id", &ids).Error
+	return len(ids) > 0, err
+}
@@ -5,10 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"net/http"
-	"os"
-	"path/filepath"
-	"plate-recognizer-api/internal/minio"
+	"plate-recognizer-api/internal/platematch"
+	"plate-recognizer-api/internal/platenorm"
 	"plate-recognizer-api/model"
 	"strings"
 	"time"
@@ -16,12 +14,6 @@ import (
 	"gorm.io/gorm"
 )
 
-type MemberCheckResponse struct {
-	Data struct {
-		Category string `json:"category"`
-	} `json:"data"`
-}
-
 type FinalResponse struct {
 	Status  int         `json:"status"`
 	Message string      `json:"message"`
@@ -29,67 +21,172 @@ type FinalResponse struct {
 	Code    string      `json:"code"`
 }
 
-func RecognizeAndSavePlateLog(
+// RecognizeRequest is the gate input for one recognition.
+type RecognizeRequest struct {
+	ImagePath     string
+	LocationCode  string
+	TransactionNo string
+	CameraID      string
+	MMC           string
+	Regions       []string
+	EngineConfig  *EngineConfig
+
+	// MinConfidence overrides PlateLogService.MinConfidence
+	MinConfidence *float64
+
+	// Country selects the plate format, it overrides PlateLogService.Country
+	Country string
+
+	// Direction is "entry" or "exit"; empty skips parking sessions
+	Direction string
+}
+
+// PlateLogService runs the recognition pipeline and records plate logs.
+type PlateLogService struct {
+	DB      *gorm.DB
+	Engine  RecognitionEngine
+	Members MemberClient
+	Budget  Budget
+
+	// Optional degraded mode for member service outages
+	MemberPolicy MemberFailurePolicy
+	Reconciler   *MemberReconciler
+
+	// Optional background image storage
+	Uploader *ImageUploader
+
+	// Optional pairing of entry and exit reads
+	Sessions *ParkingSessionService
+
+	// Reads scoring below MinConfidence are stored for review and skip
+	// the member lookup and parking sessions
+	MinConfidence float64
+
+	// Default plate format for normalization, e.g. "id"
+	Country string
+
+	// Optional fuzzy member lookup when the exact plate is not a member,
+	// trying at most FuzzyMemberLookups close plates
+	Matcher            *platematch.Matcher
+	FuzzyMemberLookups int
+
+	// Optional camera registry, reviews use it for the plate format and
+	// direction of the camera a read came from
+	Cameras *CameraService
+}
+
+func NewPlateLogService(
 	db *gorm.DB,
-	token string,
-	imagePath string,
-	locationCode string,
-	transactionNo string,
-	cameraID string,
-	mmc string,
+	engine RecognitionEngine,
+	members MemberClient,
+	budget Budget,
+) *PlateLogService {
+	return &PlateLogService{
+		DB:      db,
+		Engine:  engine,
+		Members: members,
+		Budget:  budget,
+	}
+}
+
+// RecognizeAndSavePlateLog reads the plate, looks up the member and
+// stores the log, then hands the image to the background uploader. Each
+// stage runs under its share of the Budget and the pipeline stops as
+// soon as ctx is cancelled.
+func (s *PlateLogService) RecognizeAndSavePlateLog(
+	ctx context.Context,
+	req RecognizeRequest,
 ) (*FinalResponse, error) {
+	ctx, cancel := s.Budget.Request(ctx)
+	defer cancel()
+
+	imagePath := req.ImagePath
+	locationCode := req.LocationCode
+	transactionNo := req.TransactionNo
+	cameraID := req.CameraID
+	mmc := req.MMC
 
-	// --- Call plate recognizer ---
-	plate, score, err := Recognize(
-		token,
+	// --- Call recognition engine ---
+	engineCtx, engineCancel := s.Budget.Stage(ctx, s.Budget.Engine)
+	rec, err := Recognize(
+		engineCtx,
+		s.Engine,
 		imagePath,
-		mmc,
-		cameraID,
-		transactionNo,
+		RecognizeOptions{
+			MMC:           mmc,
+			CameraID:      cameraID,
+			TransactionNo: transactionNo,
+			Regions:       req.Regions,
+			Config:        req.EngineConfig,
+		},
 	)
+	engineCancel()
 	if err != nil {
 		return nil, err
 	}
 
-	plate = strings.ToUpper(plate)
-
-	// --- Call member service ---
-	resp, err := http.Get("http://backend-app-local:5000/api/members/check-plat/" + plate)
-	if err != nil {
-		log.Println("Error checking member status:", err)
-		return nil, err
+	candidates := rec.Candidates
+	for i := range candidates {
+		candidates[i].Plate = strings.ToUpper(candidates[i].Plate)
+		for j := range candidates[i].Alternates {
+			candidates[i].Alternates[j].Plate = strings.ToUpper(candidates[i].Alternates[j].Plate)
+		}
 	}
-	defer resp.Body.Close()
 
-	log.Println("Member service HTTP status:", resp.StatusCode)
+	// The first result is the primary read, the rest are other plates in frame
+	country := req.Country
+	if country == "" {
+		country = s.Country
+	}
+	rules := platenorm.For(country)
+	norm := rules.Normalize(candidates[0].Plate)
+	plate := norm.Normalized
+	score := candidates[0].Score
 
-	// --- Check HTTP status ---
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("member service returned %d", resp.StatusCode)
+	alternates := make([]platematch.Alternate, 0, len(candidates[0].Alternates))
+	for _, a := range candidates[0].Alternates {
+		alternates = append(alternates, platematch.Alternate{
+			Plate: rules.Normalize(a.Plate).Normalized,
+			Score: a.Score,
+		})
 	}
 
-	// ======================
-	// Decode JSON response
-	// ======================
-	var memberResp MemberCheckResponse
-	if err := json.NewDecoder(resp.Body).Decode(&memberResp); err != nil {
-		log.Println("JSON decode error:", err)
-		return nil, err
+	minConfidence := s.MinConfidence
+	if req.MinConfidence != nil {
+		minConfidence = *req.MinConfidence
 	}
+	lowConfidence := score < minConfidence
 
-	if memberResp.Data.Category == "" {
-		memberResp.Data.Category = "CASUAL"
+	data := map[string]interface{}{
+		"plate":           plate,
+		"plate_raw":       norm.Raw,
+		"plate_formatted": norm.Formatted,
+		"plate_valid":     norm.Valid,
+		"score":           score,
+		"plates":          candidates,
 	}
 
 	finalResp := FinalResponse{
 		Status:  200,
 		Message: "plate recognized successfully",
 		Code:    "SUCCESS",
-		Data: map[string]interface{}{
-			"plate":         plate,
-			"score":         score,
-			"status_member": memberResp.Data.Category,
-		},
+		Data:    data,
+	}
+
+	var member *memberResult
+	if lowConfidence {
+		// The plate may be wrong, leave the decision to an operator
+		data["min_confidence"] = minConfidence
+		data["review_status"] = model.ReviewPending
+		finalResp.Code = "LOW_CONFIDENCE"
+		finalResp.Message = "plate read below confidence threshold, queued for review"
+	} else {
+		// --- Call member service ---
+		member, err = s.checkMember(ctx, rules, plate, alternates)
+		if err != nil {
+			return nil, err
+		}
+		member.addTo(data)
 	}
 
 	// --- Request metadata ---
@@ -99,44 +196,11 @@ func RecognizeAndSavePlateLog(
 		"mmc":           mmc,
 	}
 
-	// ======================================================
-	// ================= MINIO UPLOAD =======================
-	// ======================================================
-
-	log.Println("MINIO_ENDPOINT =", os.Getenv("MINIO_ENDPOINT"))
-	log.Println("MINIO_BUCKET_IMAGE_LPR =", os.Getenv("MINIO_BUCKET_IMAGE_LPR"))
-	log.Println("MINIO_USE_SSL =", os.Getenv("MINIO_USE_SSL"))
-
-	minioBucket := os.Getenv("MINIO_BUCKET_IMAGE_LPR")
-
-	if minioBucket != "" {
-		mc, err := minio.New()
-		if err != nil {
-			log.Printf("MinIO init failed: %v", err)
-		} else {
-			objName := fmt.Sprintf(
-				"%s-%d-%s",
-				cameraID,
-				time.Now().Unix(),
-				filepath.Base(imagePath),
-			)
-
-			url, err := mc.UploadFile(
-				context.Background(),
-				minioBucket,
-				objName,
-				imagePath,
-			)
-			if err != nil {
-				log.Printf("MinIO upload failed: %v", err)
-			} else {
-				requestMeta["image_url"] = url
-			}
-		}
+	// Don't write a log row for a request nobody is waiting for anymore
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	// ======================================================
-
 	requestJSON, _ := json.Marshal(requestMeta)
 	responseFinalJSON, _ := json.Marshal(finalResp)
 
@@ -144,23 +208,170 @@ func RecognizeAndSavePlateLog(
 		LocationCode:  locationCode,
 		CameraID:      cameraID,
 		Plate:         plate,
+		PlateRaw:      norm.Raw,
 		TransactionNo: transactionNo,
 		Timestamp:     time.Now(),
 		RequestData:   string(requestJSON),
 		Accuracy:      fmt.Sprintf("%.2f", score),
-		ResponseData:  "",
+		ResponseData:  string(rec.Raw),
 		ResponseFinal: string(responseFinalJSON),
-		ImageURL:      requestMeta["image_url"],
+
+		EngineURL:       rec.EngineURL,
+		EngineLatencyMs: rec.Latency.Milliseconds(),
+		EngineStatus:    rec.HTTPStatus,
 	}
+	if lowConfidence {
+		plateLog.ReviewStatus = model.ReviewPending
+	} else {
+		member.setOn(&plateLog)
+	}
+	// Retention must not purge the row before the upload back-fills it
+	plateLog.ImagePending = s.Uploader != nil
+
+	dbCtx, dbCancel := s.Budget.Stage(ctx, s.Budget.DB)
+	defer dbCancel()
+	db := s.DB.WithContext(dbCtx)
 
 	if err := db.Create(&plateLog).Error; err != nil {
 		return nil, err
 	}
 
-	// Update request_data (with image_url if exists)
-	if reqJSON2, err := json.Marshal(requestMeta); err == nil {
-		db.Model(&plateLog).Update("request_data", string(reqJSON2))
+	// The image is stored in the background, image_url is back-filled
+	if s.Uploader != nil {
+		if err := s.Uploader.Enqueue(&plateLog, imagePath); err != nil {
+			log.Printf("failed to queue image upload for log %d: %v", plateLog.ID, err)
+			if err := db.Model(&plateLog).Update("image_pending", false).Error; err != nil {
+				log.Printf("failed to clear pending image of log %d: %v", plateLog.ID, err)
+			}
+		}
+	}
+
+	if !lowConfidence {
+		s.afterSave(dbCtx, &plateLog, req.Direction, alternates, member, data)
 	}
 
 	return &finalResp, nil
 }
+
+// memberResult is the outcome of the member stage of a read.
+type memberResult struct {
+	Member *MemberInfo
+	Match  *platematch.Match
+
+	// Lookup failure served with the fallback category in degraded mode
+	Err error
+}
+
+// checkMember looks the plate up under the member stage budget, trying
+// close plates when it is not a member. Without degraded mode a failed
+// lookup is returned as error.
+func (s *PlateLogService) checkMember(
+	ctx context.Context,
+	rules platenorm.Rules,
+	plate string,
+	alternates []platematch.Alternate,
+) (*memberResult, error) {
+	memberCtx, memberCancel := s.Budget.Stage(ctx, s.Budget.Member)
+	defer memberCancel()
+
+	res := &memberResult{}
+	member, err := s.Members.CheckPlate(memberCtx, plate)
+	if err == nil && member.Category == "" {
+		if match, info := s.fuzzyMember(memberCtx, rules, plate, alternates); match != nil {
+			member = info
+			res.Match = match
+		}
+	}
+	if err != nil {
+		log.Println("Error checking member status:", err)
+		if !s.MemberPolicy.Degraded {
+			return nil, err
+		}
+		// The plate is already read, don't keep the barrier closed
+		member = &MemberInfo{Category: s.MemberPolicy.FallbackCategory}
+		res.Err = err
+	}
+
+	if member.Category == "" {
+		member.Category = "CASUAL"
+	}
+	res.Member = member
+	return res, nil
+}
+
+func (r *memberResult) addTo(data map[string]interface{}) {
+	data["status_member"] = r.Member.Category
+	data["member"] = r.Member
+	if r.Match != nil {
+		data["member_match"] = r.Match
+	}
+	if r.Err != nil {
+		data["member_degraded"] = true
+	}
+}
+
+func (r *memberResult) setOn(plateLog *model.PlateLog) {
+	plateLog.MemberCategory = r.Member.Category
+	plateLog.MemberID = r.Member.MemberID
+	plateLog.MemberDegraded = r.Err != nil
+}
+
+// afterSave pairs a stored read with the parking sessions and queues a
+// degraded member lookup for reconciliation. Failures are only logged,
+// the log is already stored.
+func (s *PlateLogService) afterSave(
+	ctx context.Context,
+	plateLog *model.PlateLog,
+	direction string,
+	alternates []platematch.Alternate,
+	member *memberResult,
+	data map[string]interface{},
+) {
+	if s.Sessions != nil && direction != "" {
+		session, err := s.Sessions.RecordRead(ctx, plateLog, direction, alternates)
+		if err != nil {
+			log.Printf("failed to record parking session for log %d: %v", plateLog.ID, err)
+		} else if session != nil {
+			data["session"] = session
+		}
+	}
+
+	// Queue the failed lookup so it can be reconciled later
+	if member != nil && member.Err != nil && s.Reconciler != nil {
+		if err := s.Reconciler.Enqueue(ctx, plateLog, member.Member.Category, member.Err); err != nil {
+			log.Printf("failed to queue member reconciliation for log %d: %v", plateLog.ID, err)
+		}
+	}
+}
+
+// fuzzyMember looks up the plates closest to a read that is not a
+// member. Lookup errors end the search, the exact result stands.
+func (s *PlateLogService) fuzzyMember(
+	ctx context.Context,
+	rules platenorm.Rules,
+	plate string,
+	alternates []platematch.Alternate,
+) (*platematch.Match, *MemberInfo) {
+	if s.Matcher == nil || s.FuzzyMemberLookups <= 0 {
+		return nil, nil
+	}
+
+	// Only look up plates the country format allows as they are
+	valid := func(p string) bool {
+		r := rules.Normalize(p)
+		return r.Valid && r.Normalized == p
+	}
+
+	for _, m := range s.Matcher.Candidates(plate, alternates, s.FuzzyMemberLookups, valid) {
+		info, err := s.Members.CheckPlate(ctx, m.Plate)
+		if err != nil {
+			log.Printf("fuzzy member lookup %s failed: %v", m.Plate, err)
+			return nil, nil
+		}
+		if info.Category != "" {
+			return &m, info
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"plate-recognizer-api/model"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// dryRunDB builds SQL without a database and hands every created row
+// to created.
+func dryRunDB(t *testing.T, created func(interface{})) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Callback().Create().After("gorm:create").Register("test:capture", func(tx *gorm.DB) {
+		created(tx.Statement.Dest)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// slowMemberClient answers once ctx is done, like a hung member backend.
+type slowMemberClient struct{}
+
+func (slowMemberClient) CheckPlate(ctx context.Context, plate string) (*MemberInfo, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// unusedMemberClient fails the test when the member stage runs.
+type unusedMemberClient struct{ t *testing.T }
+
+func (m unusedMemberClient) CheckPlate(ctx context.Context, plate string) (*MemberInfo, error) {
+	m.t.Errorf("member lookup of %s, want none", plate)
+	return &MemberInfo{}, nil
+}
+
+func TestRecognizeAndSavePlateLog(t *testing.T) {
+	image := filepath.Join(t.TempDir(), "gate.jpg")
+	if err := os.WriteFile(image, []byte("jpeg"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	engineDown := errors.New("engine down")
+	read := []Candidate{{Plate: "b 1234 xyz", Score: 0.9}}
+
+	tests := []struct {
+		name     string
+		engine   *FakeEngine
+		members  func(t *testing.T) MemberClient
+		policy   MemberFailurePolicy
+		minScore float64
+
+		wantErr      error
+		wantCode     string
+		wantCategory string
+		wantDegraded bool
+		wantReview   string
+	}{
+		{
+			name:    "engine error",
+			engine:  &FakeEngine{Err: engineDown},
+			members: func(t *testing.T) MemberClient { return unusedMemberClient{t} },
+			wantErr: engineDown,
+		},
+		{
+			name:       "low confidence",
+			engine:     &FakeEngine{Candidates: []Candidate{{Plate: "B1234XYZ", Score: 0.4}}},
+			members:    func(t *testing.T) MemberClient { return unusedMemberClient{t} },
+			minScore:   0.5,
+			wantCode:   "LOW_CONFIDENCE",
+			wantReview: model.ReviewPending,
+		},
+		{
+			name:   "member hit",
+			engine: &FakeEngine{Candidates: read},
+			members: func(t *testing.T) MemberClient {
+				return &StubMemberClient{Members: map[string]MemberInfo{
+					"B1234XYZ": {Category: "MEMBER", MemberID: "m-1"},
+				}}
+			},
+			wantCode:     "SUCCESS",
+			wantCategory: "MEMBER",
+		},
+		{
+			name:         "member miss",
+			engine:       &FakeEngine{Candidates: read},
+			members:      func(t *testing.T) MemberClient { return &StubMemberClient{} },
+			wantCode:     "SUCCESS",
+			wantCategory: "CASUAL",
+		},
+		{
+			name:    "member timeout",
+			engine:  &FakeEngine{Candidates: read},
+			members: func(t *testing.T) MemberClient { return slowMemberClient{} },
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name:         "member timeout degraded",
+			engine:       &FakeEngine{Candidates: read},
+			members:      func(t *testing.T) MemberClient { return slowMemberClient{} },
+			policy:       MemberFailurePolicy{Degraded: true, FallbackCategory: "UNKNOWN"},
+			wantCode:     "SUCCESS",
+			wantCategory: "UNKNOWN",
+			wantDegraded: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs []*model.PlateLog
+			db := dryRunDB(t, func(dest interface{}) {
+				if l, ok := dest.(*model.PlateLog); ok {
+					logs = append(logs, l)
+				}
+			})
+
+			s := NewPlateLogService(db, tt.engine, tt.members(t), Budget{
+				Total:  time.Second,
+				Member: 20 * time.Millisecond,
+			})
+			s.Country = "id"
+			s.MinConfidence = tt.minScore
+			s.MemberPolicy = tt.policy
+
+			resp, err := s.RecognizeAndSavePlateLog(context.Background(), RecognizeRequest{
+				ImagePath:     image,
+				LocationCode:  "HQ",
+				TransactionNo: "T-1",
+				CameraID:      "cam-1",
+			})
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				if len(logs) != 0 {
+					t.Fatalf("stored %d logs after a failed read", len(logs))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.Code != tt.wantCode {
+				t.Errorf("code = %s, want %s", resp.Code, tt.wantCode)
+			}
+			if len(logs) != 1 {
+				t.Fatalf("stored %d logs, want 1", len(logs))
+			}
+
+			got := logs[0]
+			if got.Plate != "B1234XYZ" {
+				t.Errorf("plate = %s, want B1234XYZ", got.Plate)
+			}
+			if got.MemberCategory != tt.wantCategory {
+				t.Errorf("member category = %q, want %q", got.MemberCategory, tt.wantCategory)
+			}
+			if got.MemberDegraded != tt.wantDegraded {
+				t.Errorf("member degraded = %v, want %v", got.MemberDegraded, tt.wantDegraded)
+			}
+			if got.ReviewStatus != tt.wantReview {
+				t.Errorf("review status = %q, want %q", got.ReviewStatus, tt.wantReview)
+			}
+
+			data := resp.Data.(map[string]interface{})
+			if tt.wantCategory != "" && data["status_member"] != tt.wantCategory {
+				t.Errorf("status_member = %v, want %s", data["status_member"], tt.wantCategory)
+			}
+		})
+	}
+}
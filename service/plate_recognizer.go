@@ -2,27 +2,143 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"mime/multipart"
 	"net/http"
-	"os"
-	"plate-recognizer-api/utils"
+	"plate-recognizer-api/internal/readerpool"
 	"time"
 )
 
-var rrCounter uint64
-
+// Response mirrors the Plate Recognizer SDK /v1/plate-reader/ payload.
 type Response struct {
-	Results []struct {
-		Plate string  `json:"plate"`
-		Score float64 `json:"score"`
-	} `json:"results"`
+	ProcessingTime float64       `json:"processing_time"`
+	Results        []PlateResult `json:"results"`
+	Filename       string        `json:"filename"`
+	Version        int           `json:"version"`
+	CameraID       string        `json:"camera_id"`
+	Timestamp      string        `json:"timestamp"`
+}
+
+type PlateResult struct {
+	Box         Box                `json:"box"`
+	Plate       string             `json:"plate"`
+	Region      Region             `json:"region"`
+	Score       float64            `json:"score"`
+	Candidates  []PlateCandidate   `json:"candidates"`
+	DScore      float64            `json:"dscore"`
+	Vehicle     Vehicle            `json:"vehicle"`
+	ModelMake   []ModelMake        `json:"model_make"`
+	Color       []VehicleColor     `json:"color"`
+	Orientation []VehicleDirection `json:"orientation"`
+}
+
+type Box struct {
+	XMin int `json:"xmin"`
+	YMin int `json:"ymin"`
+	XMax int `json:"xmax"`
+	YMax int `json:"ymax"`
+}
+
+type Region struct {
+	Code  string  `json:"code"`
+	Score float64 `json:"score"`
+}
+
+type PlateCandidate struct {
+	Plate string  `json:"plate"`
+	Score float64 `json:"score"`
+}
+
+type Vehicle struct {
+	Score float64 `json:"score"`
+	Type  string  `json:"type"`
+	Box   Box     `json:"box"`
 }
 
-func Recognize(token, imagePath, mmc, cameraID string, transactionNo string) (string, float64, error) {
+type ModelMake struct {
+	Make  string  `json:"make"`
+	Model string  `json:"model"`
+	Score float64 `json:"score"`
+}
+
+type VehicleColor struct {
+	Color string  `json:"color"`
+	Score float64 `json:"score"`
+}
+
+type VehicleDirection struct {
+	Orientation string  `json:"orientation"`
+	Score       float64 `json:"score"`
+}
+
+// toCandidate converts an SDK result into the engine-neutral Candidate.
+func (r PlateResult) toCandidate() Candidate {
+	box := r.Box
+	region := r.Region
+
+	c := Candidate{
+		Plate:      r.Plate,
+		Score:      r.Score,
+		DScore:     r.DScore,
+		Box:        &box,
+		Alternates: r.Candidates,
+	}
+
+	if region.Code != "" {
+		c.Region = &region
+	}
+
+	if r.Vehicle.Type != "" {
+		vbox := r.Vehicle.Box
+		v := &VehicleInfo{
+			Type:  r.Vehicle.Type,
+			Score: r.Vehicle.Score,
+			Box:   &vbox,
+		}
+		// MMC attributes are sorted by score, keep the best one
+		if len(r.Color) > 0 {
+			v.Color = r.Color[0].Color
+		}
+		if len(r.ModelMake) > 0 {
+			v.Make = r.ModelMake[0].Make
+			v.Model = r.ModelMake[0].Model
+		}
+		if len(r.Orientation) > 0 {
+			v.Orientation = r.Orientation[0].Orientation
+		}
+		c.Vehicle = v
+	}
+
+	return c
+}
+
+// PlateRecognizerEngine talks to the Plate Recognizer SDK container
+// (platerecognizer/alpr).
+type PlateRecognizerEngine struct {
+	Token  string
+	Pool   *readerpool.Pool
+	Client *http.Client
+}
+
+func NewPlateRecognizerEngine(token string, pool *readerpool.Pool) *PlateRecognizerEngine {
+	return &PlateRecognizerEngine{
+		Token: token,
+		Pool:  pool,
+		Client: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}
+}
+
+func (e *PlateRecognizerEngine) Recognize(
+	ctx context.Context,
+	image []byte,
+	opts RecognizeOptions,
+) (*Recognition, error) {
 	start := time.Now()
 
 	// Log execution time
@@ -30,73 +146,59 @@ func Recognize(token, imagePath, mmc, cameraID string, transactionNo string) (st
 		log.Println("⏱ PlateRecognizer duration:", time.Since(start))
 	}()
 
-	file, err := os.Open(imagePath)
-	if err != nil {
-		return "", 0, err
-	}
-	defer file.Close()
-
 	var body bytes.Buffer
 	writer := multipart.NewWriter(&body)
 
 	// Add image file
 	part, err := writer.CreateFormFile("upload", "image.jpg")
 	if err != nil {
-		return "", 0, err
+		return nil, err
 	}
-	if _, err := io.Copy(part, file); err != nil {
-		return "", 0, err
+	if _, err := part.Write(image); err != nil {
+		return nil, err
 	}
 
 	// Add extra fields
-	timestamp := time.Now().Format(time.RFC3339)
+	timestamp := opts.Timestamp.Format(time.RFC3339)
 	_ = writer.WriteField("timestamp", timestamp)
-	_ = writer.WriteField("mmc", mmc)
-	_ = writer.WriteField("camera_id", cameraID)
+	_ = writer.WriteField("mmc", opts.MMC)
+	_ = writer.WriteField("camera_id", opts.CameraID)
+	for _, region := range opts.Regions {
+		_ = writer.WriteField("regions", region)
+	}
+	var config []byte
+	if opts.Config != nil {
+		if config, err = json.Marshal(opts.Config); err != nil {
+			return nil, err
+		}
+		_ = writer.WriteField("config", string(config))
+	}
 
 	_ = writer.Close()
 
-	// Round-robin selection between two endpoints
-	// endpoints := [2]string{
-	// 	"http://plate-recognizer:8080/v1/plate-reader/",
-	// 	"http://plate-recognizer:8081/v1/plate-reader/",
-	// }
-	// endpoints := [2]string{
-	// 	"http://localhost:8080/v1/plate-reader/",
-	// 	"http://localhost:8081/v1/plate-reader/",
-	// }
-	// idx := atomic.AddUint64(&rrCounter, 1) % 2
-	// url := endpoints[idx]
-
-	// req, err := http.NewRequest(
-	// 	http.MethodPost,
-	// 	url,
-	// 	&body,
-	// )
-	// if err != nil {
-	// 	return "", 0, err
-	// }
-
 	// 1️⃣ get healthy endpoint
-	url, err := utils.GetHealthyPlateReaderURL()
+	lease, err := e.Pool.Acquire()
 	if err != nil {
-		return "", 0, err
+		return nil, err
 	}
+	url := lease.URL
 
 	log.Println("🚀 Sending request to:", url)
 
 	// 2️⃣ create request (IMPORTANT)
-	req, err := http.NewRequest(
+	req, err := http.NewRequestWithContext(
+		ctx,
 		http.MethodPost,
 		url,
 		&body,
 	)
 	if err != nil {
-		return "", 0, err
+		lease.Release(nil)
+		return nil, err
 	}
 
 	// 3️⃣ set headers AFTER request is created
-	req.Header.Set("Authorization", "Token "+token)
+	req.Header.Set("Authorization", "Token "+e.Token)
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 
 	// ======================
@@ -104,25 +206,32 @@ func Recognize(token, imagePath, mmc, cameraID string, transactionNo string) (st
 	// ======================
 	log.Println("🚀 PlateRecognizer REQUEST")
 	log.Println("URL       :", req.URL.String())
-	log.Println("MMC       :", mmc)
-	log.Println("Camera ID :", cameraID)
+	log.Println("MMC       :", opts.MMC)
+	log.Println("Camera ID :", opts.CameraID)
+	log.Println("Regions   :", opts.Regions)
+	log.Println("Config    :", string(config))
 	log.Println("Timestamp :", timestamp)
 	log.Println("Body size :", body.Len(), "bytes")
 
 	// 4️⃣ send request
-	client := &http.Client{
-		Timeout: 15 * time.Second,
-	}
-
-	resp, err := client.Do(req)
+	resp, err := e.Client.Do(req)
 	if err != nil {
-		return "", 0, err
+		lease.Release(err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", 0, err
+		lease.Release(err)
+		return nil, err
+	}
+
+	// Only server errors count against the endpoint, 4xx are our fault
+	if resp.StatusCode >= http.StatusInternalServerError {
+		lease.Release(fmt.Errorf("plate recognizer returned %d", resp.StatusCode))
+	} else {
+		lease.Release(nil)
 	}
 
 	// ======================
@@ -132,14 +241,25 @@ func Recognize(token, imagePath, mmc, cameraID string, transactionNo string) (st
 	log.Println("Status :", resp.Status)
 	log.Println("Body   :", string(respBody))
 
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("plate recognizer returned %d", resp.StatusCode)
+	}
+
 	var result Response
 	if err := json.Unmarshal(respBody, &result); err != nil {
-		return "", 0, err
+		return nil, err
 	}
 
-	if len(result.Results) == 0 {
-		return "", 0, fmt.Errorf("no plate detected")
+	candidates := make([]Candidate, 0, len(result.Results))
+	for _, r := range result.Results {
+		candidates = append(candidates, r.toCandidate())
 	}
 
-	return result.Results[0].Plate, result.Results[0].Score, nil
+	return &Recognition{
+		Candidates: candidates,
+		Raw:        respBody,
+		EngineURL:  url,
+		Latency:    time.Since(start),
+		HTTPStatus: resp.StatusCode,
+	}, nil
 }
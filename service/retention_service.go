@@ -0,0 +1,401 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"plate-recognizer-api/model"
+
+	"gorm.io/gorm"
+)
+
+// ObjectRemover deletes stored images, e.g. the MinIO client.
+type ObjectRemover interface {
+	RemoveObject(ctx context.Context, bucket, objectName string) error
+}
+
+// RetentionReport describes what a purge removed, or would remove when
+// DryRun is set.
+type RetentionReport struct {
+	GeneratedAt time.Time                 `json:"generated_at"`
+	DryRun      bool                      `json:"dry_run"`
+	Locations   []LocationRetentionReport `json:"locations"`
+}
+
+type LocationRetentionReport struct {
+	LocationCode   string     `json:"location_code"`
+	ImageDays      int        `json:"image_days"`
+	MetadataDays   int        `json:"metadata_days"`
+	ImageCutoff    *time.Time `json:"image_cutoff,omitempty"`
+	MetadataCutoff *time.Time `json:"metadata_cutoff,omitempty"`
+	Images         int64      `json:"images"`
+	Rows           int64      `json:"rows"`
+	Errors         []string   `json:"errors,omitempty"`
+}
+
+// RetentionService purges old images and plate logs per location.
+type RetentionService struct {
+	DB      *gorm.DB
+	Storage ObjectRemover
+
+	DefaultImageDays    int
+	DefaultMetadataDays int
+	BatchSize           int
+}
+
+func NewRetentionService(db *gorm.DB, storage ObjectRemover, imageDays, metadataDays int) *RetentionService {
+	return &RetentionService{
+		DB:                  db,
+		Storage:             storage,
+		DefaultImageDays:    imageDays,
+		DefaultMetadataDays: metadataDays,
+		BatchSize:           500,
+	}
+}
+
+// Start runs the purge every interval until ctx is cancelled.
+func (r *RetentionService) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				report, err := r.Run(ctx, false)
+				if err != nil {
+					log.Printf("retention purge failed: %v", err)
+					continue
+				}
+				for _, l := range report.Locations {
+					log.Printf(
+						"retention purge %s: %d images, %d rows removed, %d errors",
+						l.LocationCode,
+						l.Images,
+						l.Rows,
+						len(l.Errors),
+					)
+				}
+			}
+		}
+	}()
+}
+
+// Policies returns the effective policy of every location with logs or
+// an explicit policy.
+func (r *RetentionService) Policies(ctx context.Context) ([]model.RetentionPolicy, error) {
+	db := r.DB.WithContext(ctx)
+
+	var custom []model.RetentionPolicy
+	if err := db.Order("location_code").Find(&custom).Error; err != nil {
+		return nil, err
+	}
+
+	var locations []string
+	if err := db.Model(&model.PlateLog{}).
+		Distinct("location_code").
+		Order("location_code").
+		Pluck("location_code", &locations).Error; err != nil {
+		return nil, err
+	}
+
+	byLocation := make(map[string]model.RetentionPolicy, len(custom))
+	for _, p := range custom {
+		byLocation[p.LocationCode] = p
+	}
+
+	policies := custom
+	for _, loc := range locations {
+		if _, ok := byLocation[loc]; !ok {
+			policies = append(policies, model.RetentionPolicy{
+				LocationCode: loc,
+				ImageDays:    r.DefaultImageDays,
+				MetadataDays: r.DefaultMetadataDays,
+			})
+		}
+	}
+
+	return policies, nil
+}
+
+// SetPolicy creates or updates the policy of a location.
+func (r *RetentionService) SetPolicy(ctx context.Context, locationCode string, imageDays, metadataDays int) (*model.RetentionPolicy, error) {
+	if locationCode == "" {
+		return nil, errors.New("location_code is required")
+	}
+	if imageDays < 0 || metadataDays < 0 {
+		return nil, errors.New("retention days must not be negative")
+	}
+	if metadataDays > 0 && (imageDays == 0 || imageDays > metadataDays) {
+		// An image without its log row can't be found anymore
+		imageDays = metadataDays
+	}
+
+	db := r.DB.WithContext(ctx)
+
+	var policy model.RetentionPolicy
+	err := db.Where("location_code = ?", locationCode).First(&policy).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	policy.LocationCode = locationCode
+	policy.ImageDays = imageDays
+	policy.MetadataDays = metadataDays
+
+	if err := db.Save(&policy).Error; err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// DeletePolicy reverts a location to the default policy.
+func (r *RetentionService) DeletePolicy(ctx context.Context, locationCode string) error {
+	return r.DB.WithContext(ctx).
+		Where("location_code = ?", locationCode).
+		Delete(&model.RetentionPolicy{}).Error
+}
+
+// Run purges (or with dryRun, counts) everything past retention.
+func (r *RetentionService) Run(ctx context.Context, dryRun bool) (*RetentionReport, error) {
+	policies, err := r.Policies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	report := &RetentionReport{
+		GeneratedAt: now,
+		DryRun:      dryRun,
+	}
+
+	for _, p := range policies {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		l := LocationRetentionReport{
+			LocationCode: p.LocationCode,
+			ImageDays:    p.ImageDays,
+			MetadataDays: p.MetadataDays,
+		}
+
+		if p.ImageDays > 0 {
+			cutoff := now.AddDate(0, 0, -p.ImageDays)
+			l.ImageCutoff = &cutoff
+		}
+		if p.MetadataDays > 0 {
+			cutoff := now.AddDate(0, 0, -p.MetadataDays)
+			l.MetadataCutoff = &cutoff
+
+			// Rows past metadata retention take their images with them
+			if l.ImageCutoff == nil || cutoff.After(*l.ImageCutoff) {
+				l.ImageCutoff = &cutoff
+			}
+		}
+
+		if l.ImageCutoff != nil {
+			r.purgeImages(ctx, p.LocationCode, *l.ImageCutoff, dryRun, &l)
+		}
+		if l.MetadataCutoff != nil {
+			r.purgeRows(ctx, p.LocationCode, *l.MetadataCutoff, dryRun, &l)
+		}
+
+		report.Locations = append(report.Locations, l)
+	}
+
+	return report, nil
+}
+
+type retentionImage struct {
+	ID          uint
+	ImageURL    string
+	ImageBucket string
+	ImageKey    string
+}
+
+func (r *RetentionService) imageScope(ctx context.Context, locationCode string, cutoff time.Time) *gorm.DB {
+	return r.DB.WithContext(ctx).
+		Model(&model.PlateLog{}).
+		Where("location_code = ? AND timestamp < ?", locationCode, cutoff).
+		Where("(COALESCE(image_key, '') <> '' OR COALESCE(image_url, '') <> '')")
+}
+
+func (r *RetentionService) purgeImages(
+	ctx context.Context,
+	locationCode string,
+	cutoff time.Time,
+	dryRun bool,
+	l *LocationRetentionReport,
+) {
+	if dryRun {
+		var count int64
+		if err := r.imageScope(ctx, locationCode, cutoff).Count(&count).Error; err != nil {
+			l.Errors = append(l.Errors, err.Error())
+		}
+		l.Images += count
+		return
+	}
+
+	var lastID uint
+	for {
+		var batch []retentionImage
+		if err := r.imageScope(ctx, locationCode, cutoff).
+			Where("id > ?", lastID).
+			Order("id").
+			Limit(r.BatchSize).
+			Find(&batch).Error; err != nil {
+			l.Errors = append(l.Errors, err.Error())
+			return
+		}
+		if len(batch) == 0 {
+			return
+		}
+		lastID = batch[len(batch)-1].ID
+
+		var purged []uint
+		for _, img := range batch {
+			if err := r.removeImage(ctx, img); err != nil {
+				// Leave the reference, the next run tries again
+				l.Errors = append(l.Errors, fmt.Sprintf("log %d: %v", img.ID, err))
+				continue
+			}
+			purged = append(purged, img.ID)
+		}
+
+		if len(purged) > 0 {
+			if err := r.DB.WithContext(ctx).
+				Model(&model.PlateLog{}).
+				Where("id IN ?", purged).
+				Updates(map[string]interface{}{
+					"image_url":    "",
+					"image_bucket": "",
+					"image_key":    "",
+				}).Error; err != nil {
+				l.Errors = append(l.Errors, err.Error())
+				return
+			}
+			l.Images += int64(len(purged))
+		}
+	}
+}
+
+func (r *RetentionService) removeImage(ctx context.Context, img retentionImage) error {
+	bucket, key := img.ImageBucket, img.ImageKey
+	if key == "" {
+		bucket, key = parseLegacyImageURL(img.ImageURL)
+	}
+	if key == "" {
+		// Nothing we can locate, just drop the reference
+		return nil
+	}
+	if r.Storage == nil {
+		return errors.New("image storage is not configured")
+	}
+
+	return r.Storage.RemoveObject(ctx, bucket, key)
+}
+
+// parseLegacyImageURL splits "http(s)://host/bucket/object" URLs stored
+// before images were private.
+func parseLegacyImageURL(raw string) (string, string) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", ""
+	}
+
+	bucket, key, ok := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	if !ok {
+		return "", ""
+	}
+	return bucket, key
+}
+
+// rowScope selects the logs past metadata retention that can go: no
+// upload is pending and no open session starts with them. A real run
+// also waits for their image to be removed.
+func (r *RetentionService) rowScope(ctx context.Context, locationCode string, cutoff time.Time) *gorm.DB {
+	return r.DB.WithContext(ctx).
+		Model(&model.PlateLog{}).
+		Where("location_code = ? AND timestamp < ?", locationCode, cutoff).
+		Where("image_pending = ?", false).
+		Where("NOT EXISTS (?)", r.DB.Model(&model.ParkingSession{}).
+			Select("1").
+			Where("parking_sessions.entry_log_id = plate_logs.id AND parking_sessions.status = ?", model.SessionOpen))
+}
+
+func (r *RetentionService) purgeRows(
+	ctx context.Context,
+	locationCode string,
+	cutoff time.Time,
+	dryRun bool,
+	l *LocationRetentionReport,
+) {
+	// A dry run kept the images, count the rows as if they were gone
+	if dryRun {
+		var count int64
+		if err := r.rowScope(ctx, locationCode, cutoff).Count(&count).Error; err != nil {
+			l.Errors = append(l.Errors, err.Error())
+		}
+		l.Rows += count
+		return
+	}
+
+	for {
+		var ids []uint
+		if err := r.rowScope(ctx, locationCode, cutoff).
+			Where("COALESCE(image_key, '') = '' AND COALESCE(image_url, '') = ''").
+			Order("id").
+			Limit(r.BatchSize).
+			Pluck("id", &ids).Error; err != nil {
+			l.Errors = append(l.Errors, err.Error())
+			return
+		}
+		if len(ids) == 0 {
+			return
+		}
+
+		var deleted int64
+		err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var err error
+			deleted, err = deletePlateLogs(tx, ids)
+			return err
+		})
+		if err != nil {
+			l.Errors = append(l.Errors, err.Error())
+			return
+		}
+		l.Rows += deleted
+		if deleted == 0 {
+			return
+		}
+	}
+}
+
+// deletePlateLogs removes logs with the rows pointing at them. Sessions
+// they started go with them, sessions they closed keep their entry.
+func deletePlateLogs(tx *gorm.DB, ids []uint) (int64, error) {
+	if err := tx.Where("plate_log_id IN ?", ids).
+		Delete(&model.MemberReconciliation{}).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Model(&model.ParkingSession{}).
+		Where("exit_log_id IN ?", ids).
+		Update("exit_log_id", nil).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Where("entry_log_id IN ?", ids).
+		Delete(&model.ParkingSession{}).Error; err != nil {
+		return 0, err
+	}
+
+	res := tx.Where("id IN ?", ids).Delete(&model.PlateLog{})
+	return res.RowsAffected, res.Error
+}
@@ -1,17 +1,34 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"plate-recognizer-api/model"
+	"slices"
+	"strings"
 
 	"gorm.io/gorm"
 )
 
-// CreateUser creates a new user with hashed password
-func CreateUser(db *gorm.DB, username, password string) (*model.User, error) {
+var (
+	ErrInvalidRole = errors.New("role must be admin, operator or device")
+	ErrLastAdmin   = errors.New("at least one active admin must remain")
+
+	ErrBootstrapClosed = errors.New("an active admin exists, the bootstrap token is disabled")
+)
+
+// CreateUser creates a new user with hashed password. An empty role
+// creates an operator.
+func CreateUser(db *gorm.DB, username, password, role string) (*model.User, error) {
 	if username == "" || password == "" {
 		return nil, errors.New("username and password are required")
 	}
+	if role == "" {
+		role = model.RoleOperator
+	}
+	if !slices.Contains(model.Roles, role) {
+		return nil, ErrInvalidRole
+	}
 
 	// Check for existing username
 	var existing model.User
@@ -23,6 +40,7 @@ func CreateUser(db *gorm.DB, username, password string) (*model.User, error) {
 
 	user := &model.User{
 		Username: username,
+		Role:     role,
 		IsActive: true,
 	}
 
@@ -36,3 +54,179 @@ func CreateUser(db *gorm.DB, username, password string) (*model.User, error) {
 
 	return user, nil
 }
+
+// BootstrapAdmin creates the first admin with the bootstrap token. It
+// fails with ErrBootstrapClosed once an active admin exists.
+func BootstrapAdmin(ctx context.Context, db *gorm.DB, username, password string) (*model.User, error) {
+	var user *model.User
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockAdmins(tx); err != nil {
+			return err
+		}
+
+		admins, err := countActiveAdmins(tx, 0)
+		if err != nil {
+			return err
+		}
+		if admins > 0 {
+			return ErrBootstrapClosed
+		}
+
+		user, err = CreateUser(tx, username, password, model.RoleAdmin)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// HasActiveAdmin reports whether any active admin exists.
+func HasActiveAdmin(ctx context.Context, db *gorm.DB) (bool, error) {
+	admins, err := countActiveAdmins(db.WithContext(ctx), 0)
+	return admins > 0, err
+}
+
+// ListUsers returns every user ordered by username.
+func ListUsers(ctx context.Context, db *gorm.DB) ([]model.User, error) {
+	var users []model.User
+	err := db.WithContext(ctx).Order("username").Find(&users).Error
+	return users, err
+}
+
+// GetUser returns one user, gorm.ErrRecordNotFound if it doesn't exist.
+func GetUser(ctx context.Context, db *gorm.DB, id uint) (*model.User, error) {
+	var user model.User
+	if err := db.WithContext(ctx).First(&user, id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SetUserActive deactivates or reactivates a user, it won't deactivate
+// the last active admin.
+func SetUserActive(ctx context.Context, db *gorm.DB, id uint, active bool) (*model.User, error) {
+	return updateUser(ctx, db, id, func(u *model.User) error {
+		u.IsActive = active
+		return nil
+	}, "is_active")
+}
+
+// SetUserRole changes the role of a user, it won't demote the last
+// active admin.
+func SetUserRole(ctx context.Context, db *gorm.DB, id uint, role string) (*model.User, error) {
+	if !slices.Contains(model.Roles, role) {
+		return nil, ErrInvalidRole
+	}
+	return updateUser(ctx, db, id, func(u *model.User) error {
+		u.Role = role
+		return nil
+	}, "role")
+}
+
+// SetUserLocations restricts a user to the given location codes, an
+// empty list lifts the restriction.
+func SetUserLocations(ctx context.Context, db *gorm.DB, id uint, locations []string) (*model.User, error) {
+	return updateUser(ctx, db, id, func(u *model.User) error {
+		codes := make([]string, 0, len(locations))
+		for _, code := range locations {
+			if code = strings.TrimSpace(code); code != "" && !slices.Contains(codes, code) {
+				codes = append(codes, code)
+			}
+		}
+		u.Locations = strings.Join(codes, ",")
+		return nil
+	}, "locations")
+}
+
+// ResetPassword replaces the password of a user.
+func ResetPassword(ctx context.Context, db *gorm.DB, id uint, password string) (*model.User, error) {
+	if password == "" {
+		return nil, errors.New("password is required")
+	}
+	return updateUser(ctx, db, id, func(u *model.User) error {
+		return u.SetPassword(password)
+	}, "password")
+}
+
+// DeleteUser removes a user, gorm.ErrRecordNotFound if it doesn't exist
+// and ErrLastAdmin for the last active admin.
+func DeleteUser(ctx context.Context, db *gorm.DB, id uint) error {
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockAdmins(tx); err != nil {
+			return err
+		}
+
+		var user model.User
+		if err := tx.First(&user, id).Error; err != nil {
+			return err
+		}
+		if activeAdmin(&user) {
+			if err := keepAdmin(tx, user.ID); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&user).Error
+	})
+}
+
+// updateUser applies change to a user and saves columns. A change that
+// takes away the last active admin fails with ErrLastAdmin.
+func updateUser(ctx context.Context, db *gorm.DB, id uint, change func(*model.User) error, columns ...string) (*model.User, error) {
+	var user model.User
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockAdmins(tx); err != nil {
+			return err
+		}
+		if err := tx.First(&user, id).Error; err != nil {
+			return err
+		}
+
+		wasAdmin := activeAdmin(&user)
+		if err := change(&user); err != nil {
+			return err
+		}
+		if wasAdmin && !activeAdmin(&user) {
+			if err := keepAdmin(tx, user.ID); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&user).Select(columns).Updates(&user).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// lockAdmins serializes user changes until the transaction ends, two
+// admins demoting each other would otherwise both see the other one.
+func lockAdmins(tx *gorm.DB) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtext('users'))").Error
+}
+
+func activeAdmin(u *model.User) bool {
+	return u.IsActive && u.Role == model.RoleAdmin
+}
+
+// keepAdmin fails with ErrLastAdmin when no active admin other than the
+// user id is left. It must run after lockAdmins.
+func keepAdmin(tx *gorm.DB, id uint) error {
+	others, err := countActiveAdmins(tx, id)
+	if err != nil {
+		return err
+	}
+	if others == 0 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+// countActiveAdmins counts the active admins other than the user id.
+func countActiveAdmins(db *gorm.DB, exceptID uint) (int64, error) {
+	var count int64
+	err := db.Model(&model.User{}).
+		Where("id <> ? AND role = ? AND is_active = ?", exceptID, model.RoleAdmin, true).
+		Count(&count).Error
+	return count, err
+}
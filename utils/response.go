@@ -18,6 +18,17 @@ func Success(c *fiber.Ctx, status int, message string, data interface{}) error {
 	})
 }
 
+// Respond writes a handled outcome that isn't plain SUCCESS, e.g. a
+// read that was stored but needs review.
+func Respond(c *fiber.Ctx, status int, code string, message string, data interface{}) error {
+	return c.Status(status).JSON(APIResponse{
+		Status:  status,
+		Code:    code,
+		Message: message,
+		Data:    data,
+	})
+}
+
 func Error(c *fiber.Ctx, status int, code string, message string) error {
 	return c.Status(status).JSON(APIResponse{
 		Status:  status,